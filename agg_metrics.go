@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// fetchDurationBuckets suit fetch durations in seconds.
+var fetchDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// aggMetrics are what the aggregator exposes at /metrics. A nil
+// *aggMetrics records nothing.
+type aggMetrics struct {
+	registry      *prometheus.Registry
+	fetches       *prometheus.CounterVec
+	itemsInserted prometheus.Counter
+	itemsUpdated  prometheus.Counter
+	parseErrors   prometheus.Counter
+	fetchDuration *prometheus.HistogramVec
+	dueFeeds      prometheus.Gauge
+	lastSuccess   *prometheus.GaugeVec
+}
+
+func newAggMetrics() *aggMetrics {
+	m := &aggMetrics{
+		registry: prometheus.NewRegistry(),
+		fetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gator_fetches_total",
+			Help: "Feed fetches by HTTP status, or error when there was no response.",
+		}, []string{"status"}),
+		itemsInserted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gator_items_inserted_total",
+			Help: "New posts saved from fetched feeds.",
+		}),
+		itemsUpdated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gator_items_updated_total",
+			Help: "Stored posts updated from fetched feeds.",
+		}),
+		parseErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gator_parse_errors_total",
+			Help: "Fetched feeds that couldn't be parsed.",
+		}),
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gator_fetch_duration_seconds",
+			Help:    "Time taken to fetch and save a feed.",
+			Buckets: fetchDurationBuckets,
+		}, []string{"host"}),
+		dueFeeds: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gator_due_feeds",
+			Help: "Feeds due to be fetched at the start of the last cycle.",
+		}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gator_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful fetch of a feed.",
+		}, []string{"feed"}),
+	}
+	m.registry.MustRegister(m.fetches, m.itemsInserted, m.itemsUpdated, m.parseErrors, m.fetchDuration, m.dueFeeds, m.lastSuccess)
+	return m
+}
+
+// handler serves the metrics in the Prometheus exposition format.
+func (m *aggMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *aggMetrics) observeFetch(feed database.Feed, host string, run fetchRun, err error) {
+	if m == nil {
+		return
+	}
+	status := "error"
+	if run.StatusCode != 0 {
+		status = strconv.Itoa(run.StatusCode)
+	}
+	m.fetches.WithLabelValues(status).Inc()
+	m.itemsInserted.Add(float64(run.Items.New))
+	m.itemsUpdated.Add(float64(run.Items.Updated))
+	m.fetchDuration.WithLabelValues(host).Observe(time.Since(run.StartedAt).Seconds())
+
+	var parseErr *parseError
+	if errors.As(err, &parseErr) {
+		m.parseErrors.Inc()
+	}
+	if err == nil {
+		m.lastSuccess.WithLabelValues(feed.Url).Set(float64(time.Now().Unix()))
+	}
+}
+
+// observeQueue records how many feeds are waiting to be fetched.
+func (m *aggMetrics) observeQueue(s *state) {
+	if m == nil {
+		return
+	}
+	due, err := s.db.CountDueFeeds(context.Background(), sql.NullTime{Time: time.Now().UTC(), Valid: true})
+	if err != nil {
+		slog.Error("couldn't count due feeds", "error", err)
+		return
+	}
+	m.dueFeeds.Set(float64(due))
+}
+
+// startMetricsServer serves /metrics on the configured metrics_addr, if
+// any, and returns a function that stops it.
+func startMetricsServer(s *state) (func(), error) {
+	if s.cfg.MetricsAddr == "" {
+		return func() {}, nil
+	}
+	listener, err := net.Listen("tcp", s.cfg.MetricsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't serve metrics: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", s.metrics.handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := server.Serve(listener)
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server stopped", "error", err)
+		}
+	}()
+	slog.Info("serving metrics", "addr", listener.Addr().String())
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/config"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func TestObserveFetch(t *testing.T) {
+	m := newAggMetrics()
+	feed := database.Feed{Url: "https://example.com/feed"}
+	started := time.Now().Add(-time.Second)
+
+	m.observeFetch(feed, "example.com", fetchRun{StartedAt: started, StatusCode: 200, Items: feedItemStats{Seen: 3, New: 2, Updated: 1}}, nil)
+	m.observeFetch(feed, "example.com", fetchRun{StartedAt: started, StatusCode: 200}, &parseError{URL: feed.Url, Err: errors.New("bad xml")})
+	m.observeFetch(feed, "example.com", fetchRun{StartedAt: started}, errors.New("connection refused"))
+
+	rec := httptest.NewRecorder()
+	m.handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		`gator_fetches_total{status="200"} 2`,
+		`gator_fetches_total{status="error"} 1`,
+		`gator_items_inserted_total 2`,
+		`gator_items_updated_total 1`,
+		`gator_parse_errors_total 1`,
+		`gator_fetch_duration_seconds_count{host="example.com"} 3`,
+		`gator_last_success_timestamp_seconds{feed="https://example.com/feed"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+
+	// A nil *aggMetrics is a no-op
+	var none *aggMetrics
+	none.observeFetch(feed, "example.com", fetchRun{}, nil)
+}
+
+func TestStartMetricsServerReportsListenErrors(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	s := newTestStateWithConfig(t, &config.Config{MetricsAddr: busy.Addr().String()})
+	s.metrics = newAggMetrics()
+	_, err = startMetricsServer(s)
+	if err == nil {
+		t.Error("started a metrics server on an address in use")
+	}
+}
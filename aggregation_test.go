@@ -0,0 +1,583 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/config"
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/feedtest"
+	"github.com/mortalglitch/gator/internal/urlnorm"
+)
+
+// addTestFeed stores a feed for url without fetching it, so the whole of a
+// feed's script is left for the aggregator.
+func addTestFeed(t *testing.T, s *state, name, url string) database.Feed {
+	t.Helper()
+	user, err := s.db.GetUser(context.Background(), "alice")
+	if err != nil {
+		user, err = s.db.CreateUser(context.Background(), database.CreateUserParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      "alice",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	url, err = urlnorm.Normalize(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := s.db.AddFeed(context.Background(), database.AddFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name:      name,
+		Url:       url,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feed
+}
+
+// aggregate makes every feed due and runs the aggregator once.
+func aggregate(t *testing.T, s *state) {
+	t.Helper()
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, feed := range feeds {
+		err := s.db.ScheduleFeedFetch(context.Background(), database.ScheduleFeedFetchParams{ID: feed.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = scrapeAllDueFeeds(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getFeed(t *testing.T, s *state, id uuid.UUID) database.Feed {
+	t.Helper()
+	feed, err := s.db.GetFeed(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feed
+}
+
+// fetchLog returns the fetches of a feed, latest first.
+func fetchLog(t *testing.T, s *state, feedID uuid.UUID) []database.GetFetchLogRow {
+	t.Helper()
+	log, err := s.db.GetFetchLog(context.Background(), database.GetFetchLogParams{
+		FeedID: uuid.NullUUID{UUID: feedID, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func lastFetch(t *testing.T, s *state, feedID uuid.UUID) database.GetFetchLogRow {
+	t.Helper()
+	log := fetchLog(t, s, feedID)
+	if len(log) == 0 {
+		t.Fatal("feed was never fetched")
+	}
+	return log[0]
+}
+
+func postTitles(t *testing.T, s *state, feedID uuid.UUID) []string {
+	t.Helper()
+	posts, err := s.db.GetPosts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, post := range posts {
+		if post.FeedID == feedID {
+			titles = append(titles, post.Title)
+		}
+	}
+	return titles
+}
+
+func TestAggregateRSSAndAtom(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	rss := addTestFeed(t, s, "RSS", server.Script("/rss.xml", feedtest.OK(feedtest.Fixture("rss2.xml"))))
+	atom := addTestFeed(t, s, "Atom", server.Script("/atom.xml", feedtest.Atom(feedtest.Fixture("atom.xml"))))
+
+	aggregate(t, s)
+
+	if titles := postTitles(t, s, rss.ID); len(titles) != 3 {
+		t.Errorf("RSS posts = %q", titles)
+	}
+	if titles := postTitles(t, s, atom.ID); len(titles) != 2 {
+		t.Errorf("Atom posts = %q", titles)
+	}
+	for _, feed := range []database.Feed{rss, atom} {
+		run := lastFetch(t, s, feed.ID)
+		if run.StatusCode != http.StatusOK || run.Error != "" || run.Bytes == 0 {
+			t.Errorf("%s fetch = %+v", feed.Name, run)
+		}
+	}
+
+	second, err := s.db.GetPostByURL(context.Background(), "https://example.com/posts/second")
+	if err != nil || !strings.Contains(second.Content, "<em>whole</em>") {
+		t.Errorf("content:encoded wasn't kept: %+v, %v", second, err)
+	}
+	episode, err := s.db.GetPostByURL(context.Background(), "https://example.com/posts/episode-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enclosures, _ := s.db.GetEnclosuresForPost(context.Background(), episode.ID)
+	if len(enclosures) != 1 || enclosures[0].MimeType != "audio/mpeg" || enclosures[0].Length != 12345 {
+		t.Errorf("enclosures = %+v", enclosures)
+	}
+
+	requests := server.Requests("/rss.xml")
+	if len(requests) != 1 || !strings.Contains(requests[0].Header.Get("Accept"), "application/rss+xml") {
+		t.Errorf("requests = %+v", requests)
+	}
+}
+
+func TestAggregateScriptedSequence(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	day := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	a := feedtest.Item{Title: "A", Link: "https://example.com/a", Published: day}
+	b := feedtest.Item{Title: "B", Link: "https://example.com/b", Published: day.AddDate(0, 0, 1)}
+	bEdited := feedtest.Item{Title: "B, edited", Link: "https://example.com/b", Published: day.AddDate(0, 0, 1)}
+	c := feedtest.Item{Title: "C", Link: "https://example.com/c", Published: day.AddDate(0, 0, 2)}
+	d := feedtest.Item{Title: "D", Link: "https://example.com/d", Published: day.AddDate(0, 0, 3)}
+
+	feed := addTestFeed(t, s, "Sequence", server.Script("/feed.xml",
+		feedtest.OK(feedtest.RSS("Sequence", a, b)),
+		feedtest.OK(feedtest.RSS("Sequence", a, bEdited, c)),
+		feedtest.Status(http.StatusInternalServerError),
+		feedtest.OK(feedtest.RSS("Sequence", a, bEdited, c, d)),
+	))
+
+	want := []struct {
+		status             int32
+		seen, new, updated int32
+		failed             bool
+	}{
+		{status: 200, seen: 2, new: 2},
+		{status: 200, seen: 3, new: 1, updated: 1},
+		{status: 500, failed: true},
+		{status: 200, seen: 4, new: 1},
+	}
+	for i, w := range want {
+		aggregate(t, s)
+		run := lastFetch(t, s, feed.ID)
+		if run.StatusCode != w.status || run.ItemsSeen != w.seen || run.ItemsNew != w.new || run.ItemsUpdated != w.updated || (run.Error != "") != w.failed {
+			t.Errorf("fetch %d = %+v, want %+v", i+1, run, w)
+		}
+	}
+
+	titles := postTitles(t, s, feed.ID)
+	slices.Sort(titles)
+	if strings.Join(titles, "|") != "A|B, edited|C|D" {
+		t.Errorf("posts = %q", titles)
+	}
+	if n := len(server.Requests("/feed.xml")); n != len(want) {
+		t.Errorf("%d requests, want %d", n, len(want))
+	}
+	if !getFeed(t, s, feed.ID).NextFetchAt.Valid {
+		t.Error("feed wasn't rescheduled")
+	}
+}
+
+func TestAggregateBrokenXML(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	lenient := addTestFeed(t, s, "Lenient", server.Script("/broken.xml",
+		feedtest.OK(feedtest.Fixture("broken.xml")),
+		feedtest.OK(feedtest.Fixture("rss2.xml")),
+	))
+	truncated := addTestFeed(t, s, "Truncated", server.Script("/truncated.xml", feedtest.OK(feedtest.Fixture("truncated.xml"))))
+
+	aggregate(t, s)
+
+	if !getFeed(t, s, lenient.ID).ParsedWithFixes {
+		t.Error("broken feed isn't flagged")
+	}
+	if titles := postTitles(t, s, lenient.ID); len(titles) != 1 || !strings.HasPrefix(titles[0], "Cod") {
+		t.Errorf("broken feed posts = %q", titles)
+	}
+	run := lastFetch(t, s, truncated.ID)
+	if !strings.Contains(run.Error, "couldn't parse feed") {
+		t.Errorf("truncated feed fetch = %+v", run)
+	}
+	if titles := postTitles(t, s, truncated.ID); len(titles) != 0 {
+		t.Errorf("truncated feed posts = %q", titles)
+	}
+
+	// Once the feed is fixed the flag goes away
+	aggregate(t, s)
+	if getFeed(t, s, lenient.ID).ParsedWithFixes {
+		t.Error("fixed feed is still flagged")
+	}
+}
+
+func TestAggregateSlowResponses(t *testing.T) {
+	s := newTestStateWithConfig(t, &config.Config{HostRequestDelay: "0s", Timeout: "200ms"})
+	server := feedtest.NewServer(t)
+	slow := addTestFeed(t, s, "Slow", server.Script("/slow.xml",
+		feedtest.Slow(5*time.Second, feedtest.OK(feedtest.Fixture("rss2.xml"))),
+		feedtest.Slow(50*time.Millisecond, feedtest.OK(feedtest.Fixture("rss2.xml"))),
+	))
+	fast := addTestFeed(t, s, "Fast", server.Script("/fast.xml", feedtest.Atom(feedtest.Fixture("atom.xml"))))
+
+	started := time.Now()
+	aggregate(t, s)
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("aggregating took %v, the timeout is 200ms", elapsed)
+	}
+	if run := lastFetch(t, s, slow.ID); run.Error == "" || run.StatusCode != 0 {
+		t.Errorf("slow fetch = %+v", run)
+	}
+	if titles := postTitles(t, s, fast.ID); len(titles) != 2 {
+		t.Errorf("a slow feed held up another: fast feed posts = %q", titles)
+	}
+
+	// A response within the timeout is fine
+	aggregate(t, s)
+	if run := lastFetch(t, s, slow.ID); run.Error != "" {
+		t.Errorf("second slow fetch = %+v", run)
+	}
+	if titles := postTitles(t, s, slow.ID); len(titles) != 3 {
+		t.Errorf("slow feed posts = %q", titles)
+	}
+}
+
+func TestAggregateRedirects(t *testing.T) {
+	s := newTestStateWithConfig(t, &config.Config{HostRequestDelay: "0s", RedirectThreshold: 2})
+	server := feedtest.NewServer(t)
+	newURL := server.Script("/new.xml", feedtest.OK(feedtest.Fixture("rss2.xml")))
+	server.Script("/temporary.xml", feedtest.OK(feedtest.Fixture("atom.xml")))
+	moved := addTestFeed(t, s, "Moved", server.Script("/old.xml", feedtest.Redirect(http.StatusMovedPermanently, "/new.xml")))
+	temporary := addTestFeed(t, s, "Temporary", server.Script("/found.xml", feedtest.Redirect(http.StatusFound, "/temporary.xml")))
+
+	aggregate(t, s)
+	feed := getFeed(t, s, moved.ID)
+	if feed.Url != moved.Url || feed.RedirectUrl != newURL || feed.RedirectCount != 1 {
+		t.Errorf("after one permanent redirect: url %s, redirect %s x%d", feed.Url, feed.RedirectUrl, feed.RedirectCount)
+	}
+	if titles := postTitles(t, s, moved.ID); len(titles) != 3 {
+		t.Errorf("redirected feed posts = %q", titles)
+	}
+
+	aggregate(t, s)
+	if feed := getFeed(t, s, moved.ID); feed.Url != newURL {
+		t.Errorf("feed wasn't moved after two permanent redirects: %s", feed.Url)
+	}
+	if feed := getFeed(t, s, temporary.ID); feed.Url != temporary.Url || feed.RedirectCount != 0 {
+		t.Errorf("temporary redirect was tracked: %+v", feed)
+	}
+	if titles := postTitles(t, s, temporary.ID); len(titles) != 2 {
+		t.Errorf("temporarily redirected feed posts = %q", titles)
+	}
+
+	// The moved feed is now fetched from its new URL directly
+	aggregate(t, s)
+	if n := len(server.Requests("/old.xml")); n != 2 {
+		t.Errorf("old URL was requested %d times", n)
+	}
+}
+
+func TestAggregateServerErrors(t *testing.T) {
+	t.Run("500", func(t *testing.T) {
+		s := newTestState(t)
+		server := feedtest.NewServer(t)
+		feed := addTestFeed(t, s, "Broken", server.Script("/feed.xml", feedtest.Status(http.StatusInternalServerError)))
+
+		aggregate(t, s)
+		fetch := lastFetch(t, s, feed.ID)
+		if fetch.StatusCode != 500 || !strings.Contains(fetch.Error, "500") {
+			t.Errorf("fetch = %+v", fetch)
+		}
+		feed = getFeed(t, s, feed.ID)
+		if !feed.NextFetchAt.Valid || !feed.NextFetchAt.Time.After(time.Now()) || feed.DisabledAt.Valid {
+			t.Errorf("failed feed wasn't rescheduled: %+v", feed)
+		}
+
+		// agg --once reports the failure through its exit status
+		err := s.db.ScheduleFeedFetch(context.Background(), database.ScheduleFeedFetchParams{ID: feed.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = run(t, s, "agg", "--once")
+		if err == nil || !strings.Contains(err.Error(), "failed fetches: 1") {
+			t.Errorf("agg --once with a failing feed: %v", err)
+		}
+	})
+
+	t.Run("backs off while failing", func(t *testing.T) {
+		s := newTestState(t)
+		server := feedtest.NewServer(t)
+		feed := addTestFeed(t, s, "Broken", server.Script("/feed.xml", feedtest.Status(http.StatusInternalServerError)))
+
+		for range 3 {
+			aggregate(t, s)
+		}
+		// The third failure in a row waits four times the default 30m
+		next := getFeed(t, s, feed.ID).NextFetchAt
+		if !next.Valid || next.Time.Before(time.Now().Add(119*time.Minute)) || next.Time.After(time.Now().Add(2*time.Hour)) {
+			t.Errorf("next fetch at %v, want in about 2 hours", next)
+		}
+	})
+
+	t.Run("503 with Retry-After", func(t *testing.T) {
+		s := newTestState(t)
+		server := feedtest.NewServer(t)
+		feed := addTestFeed(t, s, "Busy", server.Script("/feed.xml",
+			feedtest.Status(http.StatusServiceUnavailable, "Retry-After", "120"),
+			feedtest.OK(feedtest.Fixture("rss2.xml")),
+		))
+
+		aggregate(t, s)
+		next := getFeed(t, s, feed.ID).NextFetchAt
+		if !next.Valid || next.Time.Before(time.Now().Add(110*time.Second)) || next.Time.After(time.Now().Add(121*time.Second)) {
+			t.Errorf("next fetch at %v, want in about 2 minutes", next)
+		}
+
+		// The host is left alone even when the feed is due
+		aggregate(t, s)
+		if n := len(server.Requests("/feed.xml")); n != 1 {
+			t.Errorf("host was asked %d times while deferred", n)
+		}
+	})
+
+	t.Run("410", func(t *testing.T) {
+		s := newTestState(t)
+		server := feedtest.NewServer(t)
+		feed := addTestFeed(t, s, "Gone", server.Script("/feed.xml", feedtest.Status(http.StatusGone)))
+
+		aggregate(t, s)
+		if !getFeed(t, s, feed.ID).DisabledAt.Valid {
+			t.Error("gone feed wasn't disabled")
+		}
+		aggregate(t, s)
+		if n := len(server.Requests("/feed.xml")); n != 1 {
+			t.Errorf("disabled feed was fetched %d times", n)
+		}
+
+		// The feed came back
+		server.Script("/feed.xml", feedtest.OK(feedtest.Fixture("rss2.xml")))
+		s.cfg.CurrentUserName = "alice"
+		mustRun(t, s, "enable", "Gone")
+		if getFeed(t, s, feed.ID).DisabledAt.Valid {
+			t.Error("enable didn't clear disabled_at")
+		}
+		_, err := scrapeAllDueFeeds(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(server.Requests("/feed.xml")); n != 2 {
+			t.Errorf("enabled feed was fetched %d times in all, want 2", n)
+		}
+	})
+}
+
+func TestAggregateHugeBodies(t *testing.T) {
+	s := newTestStateWithConfig(t, &config.Config{HostRequestDelay: "0s", MaxFeedSize: 64 << 10})
+	server := feedtest.NewServer(t)
+	declared := addTestFeed(t, s, "Declared", server.Script("/declared.xml", feedtest.Huge(100<<20, false)))
+	chunked := addTestFeed(t, s, "Chunked", server.Script("/chunked.xml", feedtest.Huge(100<<20, true)))
+	small := addTestFeed(t, s, "Small", server.Script("/small.xml", feedtest.Huge(1<<10, true)))
+
+	aggregate(t, s)
+	for _, feed := range []database.Feed{declared, chunked} {
+		run := lastFetch(t, s, feed.ID)
+		if !strings.Contains(run.Error, "larger than 65536 bytes") {
+			t.Errorf("%s fetch = %+v", feed.Name, run)
+		}
+	}
+	if run := lastFetch(t, s, small.ID); run.Error != "" {
+		t.Errorf("padded feed under the limit failed: %+v", run)
+	}
+}
+
+// The fetch log is how the aggregator's results are seen, so make sure
+// every attempt lands in it, failed or not.
+func TestAggregateSkipsItemsWithBadDates(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	body := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Dates</title>
+<item><title>Good</title><link>` + server.URL + `/good</link><pubDate>Mon, 01 Jan 2024 10:00:00 +0000</pubDate></item>
+<item><title>Bad</title><link>` + server.URL + `/bad</link><pubDate>the day after tomorrow</pubDate></item>
+<item><title>Undated</title><link>` + server.URL + `/undated</link></item>
+<item><title>Also Good</title><link>` + server.URL + `/also-good</link><pubDate>2024-01-02T10:00:00Z</pubDate></item>
+</channel></rss>`)
+	feed := addTestFeed(t, s, "Dates", server.Script("/feed.xml", feedtest.OK(body)))
+
+	aggregate(t, s)
+
+	titles := postTitles(t, s, feed.ID)
+	slices.Sort(titles)
+	if !slices.Equal(titles, []string{"Also Good", "Good"}) {
+		t.Errorf("posts = %q, want the two with valid dates", titles)
+	}
+	run := lastFetch(t, s, feed.ID)
+	if run.Error != "" || run.ItemsSeen != 4 || run.ItemsNew != 2 {
+		t.Errorf("fetch = %+v", run)
+	}
+}
+
+func TestAggregateLogsEveryFetch(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	feed := addTestFeed(t, s, "Missing", server.URL+"/nowhere.xml")
+
+	aggregate(t, s)
+	aggregate(t, s)
+	log := fetchLog(t, s, feed.ID)
+	if len(log) != 2 || log[0].StatusCode != http.StatusNotFound {
+		t.Errorf("fetch log = %+v", log)
+	}
+	if getFeed(t, s, feed.ID).LastFetchedAt == (sql.NullTime{}) {
+		t.Error("last fetch time wasn't recorded")
+	}
+}
+
+func TestAggregateFixedIntervalHonorsTTL(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	feed := addTestFeed(t, s, "Slow", server.Script("/feed.xml", feedtest.OK([]byte(
+		`<rss version="2.0"><channel><title>Slow</title><ttl>120</ttl></channel></rss>`))))
+	err := s.db.SetFeedInterval(context.Background(), database.SetFeedIntervalParams{
+		FetchInterval: 60,
+		UpdatedAt:     time.Now().UTC(),
+		ID:            feed.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggregate(t, s)
+	next := getFeed(t, s, feed.ID).NextFetchAt
+	if !next.Valid || next.Time.Before(time.Now().Add(119*time.Minute)) {
+		t.Errorf("next fetch at %v, want no sooner than the feed's 2h ttl", next)
+	}
+}
+
+func TestAggregateFullText(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	article := server.Script("/article", feedtest.Response{
+		Header: http.Header{"Content-Type": {"text/html"}},
+		Body: []byte(`<html><body><article><p>The whole story, told at length so it reads as the main content of the page.</p>
+<p>A second paragraph with more of the story, long enough to count as article text.</p></article></body></html>`),
+	})
+	feed := addTestFeed(t, s, "Teasers", server.Script("/feed.xml", feedtest.OK(feedtest.RSS("Teasers",
+		feedtest.Item{Title: "Story", Link: article, Description: "Just a teaser", Published: time.Now()},
+	))))
+	err := s.db.SetFeedFullText(context.Background(), database.SetFeedFullTextParams{FetchFullText: true, UpdatedAt: time.Now().UTC(), ID: feed.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = saveFeedHeaders(s, feed.ID, http.Header{"X-Api-Key": {"secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggregate(t, s)
+	aggregate(t, s)
+	requests := server.Requests("/article")
+	if len(requests) != 1 {
+		t.Fatalf("article was fetched %d times, want once for the new post", len(requests))
+	}
+	if requests[0].Header.Get("X-Api-Key") != "secret" {
+		t.Errorf("article fetch didn't send the feed's headers: %v", requests[0].Header)
+	}
+	posts, _ := s.db.GetPosts(context.Background())
+	if len(posts) != 1 || !strings.Contains(posts[0].Content, "The whole story") {
+		t.Errorf("posts = %+v", posts)
+	}
+}
+
+func TestAggregateKeepsTrailingSlash(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	// Like WordPress, the feed only lives at /feed/
+	server.Script("/feed", feedtest.Redirect(http.StatusMovedPermanently, "/feed/"))
+	feedURL := server.Script("/feed/", feedtest.OK(feedtest.Fixture("rss2.xml")))
+
+	mustRun(t, s, "register", "alice")
+	mustRun(t, s, "addfeed", feedURL)
+	feeds, _ := s.db.GetFeeds(context.Background())
+	if len(feeds) != 1 || feeds[0].Url != feedURL {
+		t.Fatalf("feeds = %+v, want %s", feeds, feedURL)
+	}
+
+	aggregate(t, s)
+	aggregate(t, s)
+	if n := len(server.Requests("/feed")); n != 0 {
+		t.Errorf("the URL without the slash was requested %d times", n)
+	}
+	if feed := getFeed(t, s, feeds[0].ID); feed.RedirectCount != 0 || lastFetch(t, s, feed.ID).Error != "" {
+		t.Errorf("feed = %+v", feed)
+	}
+}
+
+func TestAggregateFullTextIsPolite(t *testing.T) {
+	s := newTestStateWithConfig(t, &config.Config{HostRequestDelay: "50ms"})
+	server := feedtest.NewServer(t)
+	page := feedtest.Response{
+		Header: http.Header{"Content-Type": {"text/html"}},
+		Body:   []byte(`<html><body><article><p>The whole story, told at length so it reads as the main content of the page.</p></article></body></html>`),
+	}
+	var items []feedtest.Item
+	for i, path := range []string{"/1", "/2", "/3"} {
+		items = append(items, feedtest.Item{Title: path, Link: server.Script(path, page), Published: time.Now().Add(-time.Duration(i) * time.Hour)})
+	}
+	server.Script("/busy", feedtest.Status(http.StatusTooManyRequests, "Retry-After", "60"))
+	items = append(items, feedtest.Item{Title: "busy", Link: server.URL + "/busy", Published: time.Now().Add(-4 * time.Hour)})
+	server.Script("/4", page)
+	items = append(items, feedtest.Item{Title: "after busy", Link: server.URL + "/4", Published: time.Now().Add(-5 * time.Hour)})
+	feed := addTestFeed(t, s, "Teasers", server.Script("/feed.xml", feedtest.OK(feedtest.RSS("Teasers", items...))))
+	err := s.db.SetFeedFullText(context.Background(), database.SetFeedFullTextParams{FetchFullText: true, UpdatedAt: time.Now().UTC(), ID: feed.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aggregate(t, s)
+	var times []time.Time
+	for _, path := range []string{"/feed.xml", "/1", "/2", "/3"} {
+		requests := server.Requests(path)
+		if len(requests) != 1 {
+			t.Fatalf("%s was requested %d times", path, len(requests))
+		}
+		times = append(times, requests[0].Time)
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("requests %d and %d to the host were %v apart", i-1, i, gap)
+		}
+	}
+
+	// The host asked to be left alone, so the next article wasn't fetched
+	if n := len(server.Requests("/4")); n != 0 {
+		t.Errorf("article was fetched %d times after a 429", n)
+	}
+	if titles := postTitles(t, s, feed.ID); len(titles) != 5 {
+		t.Errorf("posts = %q", titles)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/xml"
+)
+
+type AtomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+type AtomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []AtomLink `xml:"link"`
+	Summary   AtomText   `xml:"summary"`
+	Content   AtomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// String returns the text as HTML. xhtml content is kept as its inner markup,
+// everything else is the decoded character data.
+func (t AtomText) String() string {
+	if t.Type == "xhtml" {
+		return t.Inner
+	}
+	return t.Text
+}
+
+// alternateLink picks the link a reader would open, preferring rel="alternate".
+// Enclosures are media files, never the page itself.
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	for _, link := range links {
+		if link.Rel != "enclosure" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// toRSS maps an Atom document onto RSSFeed so the rest of gator only deals
+// with one shape.
+func (f *AtomFeed) toRSS() *RSSFeed {
+	var rssFeed RSSFeed
+	rssFeed.Channel.Title = f.Title
+	rssFeed.Channel.Link = alternateLink(f.Links)
+	rssFeed.Channel.Description = f.Subtitle
+	for _, link := range f.Links {
+		if link.Rel == "hub" && rssFeed.Hub == "" {
+			rssFeed.Hub = link.Href
+		}
+		if link.Rel == "self" && rssFeed.Self == "" {
+			rssFeed.Self = link.Href
+		}
+	}
+	for _, entry := range f.Entries {
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        alternateLink(entry.Links),
+			Description: entry.Summary.String(),
+			Content:     entry.Content.String(),
+			PubDate:     pubDate,
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, RSSEnclosure{
+					URL:    link.Href,
+					Type:   link.Type,
+					Length: link.Length,
+				})
+			}
+		}
+		rssFeed.Channel.Item = append(rssFeed.Channel.Item, item)
+	}
+	return &rssFeed
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+var xmlEncodingDecl = regexp.MustCompile(`^(<\?xml[^>]*\bencoding\s*=\s*["'])([^"']+)(["'])`)
+
+// xmlCharsetReader lets encoding/xml read documents whose prolog declares an
+// encoding other than UTF-8.
+func xmlCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	return charset.NewReaderLabel(label, input)
+}
+
+// decodeCharset transcodes a feed to UTF-8 when the XML decoder can't do it
+// by itself: UTF-16 documents, and documents whose encoding is only given
+// by the Content-Type header. An encoding declared in the XML prolog takes
+// precedence over the header and is left to the decoder.
+func decodeCharset(dat []byte, contentType string) ([]byte, error) {
+	label := ""
+	switch {
+	case bytes.HasPrefix(dat, []byte("\xfe\xff")):
+		label = "utf-16be"
+		dat = dat[2:]
+	case bytes.HasPrefix(dat, []byte("\xff\xfe")):
+		label = "utf-16le"
+		dat = dat[2:]
+	case bytes.HasPrefix(dat, []byte("\xef\xbb\xbf")):
+		return dat, nil
+	case xmlEncodingDecl.Match(bytes.TrimSpace(dat[:min(len(dat), 256)])):
+		return dat, nil
+	default:
+		_, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return dat, nil
+		}
+		label = strings.ToLower(strings.TrimSpace(params["charset"]))
+		if label == "" || label == "utf-8" || label == "utf8" || label == "us-ascii" {
+			return dat, nil
+		}
+	}
+
+	r, err := charset.NewReaderLabel(label, bytes.NewReader(dat))
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// The prolog of a transcoded document must not send the decoder back
+	// to the original encoding
+	return xmlEncodingDecl.ReplaceAll(decoded, []byte("${1}UTF-8${3}")), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchFeedCharsets(t *testing.T) {
+	tests := []struct {
+		file        string
+		contentType string
+		title       string
+		description string
+	}{
+		{"iso-8859-1.xml", "application/rss+xml", "Café crème", "Déjà vu à Paris"},
+		{"windows-1252.xml", "application/rss+xml", "Smart “quotes” – and €uros", "It’s here"},
+		{"shift_jis.xml", "application/xml", "ニュース速報", "日本語のフィード"},
+		{"header-only-latin1.xml", "text/xml; charset=ISO-8859-1", "Straße", "Größe"},
+		{"utf-16.xml", "application/xml", "Ünïcödé", "Sixteen bits"},
+		// The prolog wins over a header that disagrees with it
+		{"iso-8859-1.xml", "text/xml; charset=utf-8", "Café crème", "Déjà vu à Paris"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.file+" "+tc.contentType, func(t *testing.T) {
+			dat, err := os.ReadFile(filepath.Join("testdata", "charset", tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write(dat)
+			}))
+			defer server.Close()
+
+			fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 1 << 20}
+			rssFeed, err := fetcher.fetchFeed(context.Background(), server.URL, nil)
+			if err != nil {
+				t.Fatalf("fetchFeed: %v", err)
+			}
+			if rssFeed.Channel.Title != tc.title {
+				t.Errorf("channel title = %q, want %q", rssFeed.Channel.Title, tc.title)
+			}
+			if len(rssFeed.Channel.Item) != 1 {
+				t.Fatalf("got %d items, want 1", len(rssFeed.Channel.Item))
+			}
+			item := rssFeed.Channel.Item[0]
+			if item.Title != tc.title || item.Description != tc.description {
+				t.Errorf("item = %q / %q, want %q / %q", item.Title, item.Description, tc.title, tc.description)
+			}
+		})
+	}
+}
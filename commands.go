@@ -18,6 +18,40 @@ type commands struct {
 	registeredCommands map[string]func(*state, command) error
 }
 
+// newCommands returns every command gator knows.
+func newCommands() commands {
+	cmds := commands{
+		registeredCommands: make(map[string]func(*state, command) error),
+	}
+	cmds.register("migrate", handlerMigrate)
+	cmds.register("login", handlerLogin)
+	cmds.register("register", handlerRegister)
+	cmds.register("reset", handlerReset)
+	cmds.register("normalize", handlerNormalize)
+	cmds.register("users", handlerListUsers)
+	cmds.register("agg", handlerAgg)
+	cmds.register("serve", handlerServe)
+	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
+	cmds.register("feeds", handlerListFeeds)
+	cmds.register("follow", middlewareLoggedIn(handlerFollow))
+	cmds.register("following", middlewareLoggedIn(handlerFollowing))
+	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
+	cmds.register("events", middlewareLoggedIn(handlerEvents))
+	cmds.register("enable", middlewareLoggedIn(handlerEnable))
+	cmds.register("fetchlog", handlerFetchLog)
+	cmds.register("fulltext", middlewareLoggedIn(handlerFullText))
+	cmds.register("interval", middlewareLoggedIn(handlerInterval))
+	cmds.register("credentials", middlewareLoggedIn(handlerCredentials))
+	cmds.register("retention", middlewareLoggedIn(handlerRetention))
+	cmds.register("prune", handlerPrune)
+	cmds.register("browse", handlerBrowse)
+	cmds.register("show", handlerShow)
+	cmds.register("enclosures", handlerEnclosures)
+	cmds.register("star", middlewareLoggedIn(handlerStar))
+	cmds.register("unstar", middlewareLoggedIn(handlerUnstar))
+	return cmds
+}
+
 func (c *commands) register(name string, f func(*state, command) error) {
 	c.registeredCommands[name] = f
 }
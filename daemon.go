@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/config"
+)
+
+// runDaemon calls work right away and then every interval until ctx is done
+// or gator receives SIGINT or SIGTERM. work is never interrupted, so fetches
+// in flight when the signal arrives are finished first, but no new run
+// starts after it. SIGHUP reloads the config into a new state, which is
+// stored in current for the next run and anything else reading it.
+func runDaemon(ctx context.Context, current *atomic.Pointer[state], interval time.Duration, work func(s *state)) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// A second signal kills gator right away
+		<-ctx.Done()
+		stop()
+	}()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	work(current.Load())
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("shutting down")
+			return
+		case <-hangup:
+			current.Store(reloadConfig(current.Load()))
+		case <-ticker.C:
+			// The tick and the signal may have arrived during the last run
+			if ctx.Err() != nil {
+				slog.Info("shutting down")
+				return
+			}
+			work(current.Load())
+		}
+	}
+}
+
+// reloadConfig rereads the config file and returns a copy of s using it, or
+// s itself when the config can't be read. s is left untouched, since HTTP
+// handlers may be reading it. The database connection is kept.
+func reloadConfig(s *state) *state {
+	cfg, err := config.Read()
+	if err != nil {
+		slog.Error("couldn't reload config, keeping the old one", "error", err)
+		return s
+	}
+	fetcher, err := newFeedFetcher(&cfg)
+	if err != nil {
+		slog.Error("couldn't reload config, keeping the old one", "error", err)
+		return s
+	}
+
+	if cfg.DBURL != s.cfg.DBURL {
+		slog.Warn("db_url changed, restart gator to use the new database")
+	}
+	next := *s
+	if cfg.MaxHostConnections() != s.cfg.MaxHostConnections() || cfg.HostDelay() != s.cfg.HostDelay() {
+		next.limiter = s.limiter.withLimits(cfg.MaxHostConnections(), cfg.HostDelay())
+	}
+	if s.websub != nil && cfg.PublicURL != "" {
+		next.websub = newWebSubscriber(cfg.PublicURL, fetcher.client)
+	}
+	next.fetcher = fetcher
+	next.cfg = &cfg
+	slog.SetDefault(newLogger(&cfg, os.Stderr))
+	slog.Info("config reloaded")
+	return &next
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/config"
+)
+
+func daemonState() *atomic.Pointer[state] {
+	var current atomic.Pointer[state]
+	current.Store(&state{})
+	return &current
+}
+
+func TestRunDaemonStopsOnSignal(t *testing.T) {
+	var runs atomic.Int32
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		runDaemon(context.Background(), daemonState(), time.Hour, func(*state) {
+			if runs.Add(1) == 1 {
+				close(started)
+				// Simulates a fetch still in flight when the signal arrives
+				<-release
+			}
+		})
+	}()
+
+	<-started
+	err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-finished:
+		t.Fatal("daemon stopped before the work in flight was done")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon didn't stop after SIGTERM")
+	}
+	if runs.Load() != 1 {
+		t.Errorf("work ran %d times, want 1", runs.Load())
+	}
+}
+
+func TestRunDaemonDoesntStartWorkAfterSignal(t *testing.T) {
+	// The interval is shorter than the work, so a tick is always waiting
+	// alongside the signal once the work is done. select picks between them
+	// at random, hence the repeats.
+	for range 20 {
+		var runs atomic.Int32
+		started := make(chan struct{})
+		finished := make(chan struct{})
+		go func() {
+			defer close(finished)
+			runDaemon(context.Background(), daemonState(), time.Millisecond, func(*state) {
+				if runs.Add(1) == 1 {
+					close(started)
+					time.Sleep(20 * time.Millisecond)
+				}
+			})
+		}()
+
+		<-started
+		err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("daemon didn't stop after SIGTERM")
+		}
+		if runs.Load() != 1 {
+			t.Fatalf("work ran %d times, want no run after the signal", runs.Load())
+		}
+	}
+}
+
+func TestRunDaemonStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		runDaemon(ctx, daemonState(), 10*time.Millisecond, func(*state) { runs.Add(1) })
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon didn't stop when its context was cancelled")
+	}
+	if runs.Load() < 2 {
+		t.Errorf("work ran %d times, want it repeated every interval", runs.Load())
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cfg := &config.Config{HostMaxConnections: 2}
+	old := &state{cfg: cfg, limiter: newHostLimiter(cfg.MaxHostConnections(), cfg.HostDelay())}
+	until := time.Now().Add(time.Hour)
+	old.limiter.deferHost("slow.example", until)
+
+	err := os.WriteFile(filepath.Join(home, ".gatorconfig.json"), []byte(`{"host_max_connections": 5, "http_timeout": "3s"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := reloadConfig(old)
+	if next == old || old.cfg != cfg {
+		t.Fatal("reloadConfig changed the running state instead of copying it")
+	}
+	if next.cfg.MaxHostConnections() != 5 || next.fetcher.client.Timeout != 3*time.Second {
+		t.Errorf("reloaded config wasn't applied: %+v", next.cfg)
+	}
+	_, err = next.limiter.acquire(context.Background(), "slow.example")
+	if err == nil {
+		t.Error("new limiter forgot the deferred host")
+	}
+}
+
+func TestReloadConfigKeepsOldConfigOnInvalidDuration(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cfg := &config.Config{HostRequestDelay: "2s"}
+	old := &state{cfg: cfg, limiter: newHostLimiter(cfg.MaxHostConnections(), cfg.HostDelay())}
+
+	err := os.WriteFile(filepath.Join(home, ".gatorconfig.json"), []byte(`{"host_request_delay": "2 seconds"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := reloadConfig(old); next != old {
+		t.Errorf("reloadConfig applied a config with an invalid duration: %+v", next.cfg)
+	}
+}
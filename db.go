@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/migrate"
+	"github.com/mortalglitch/gator/internal/sqlitedb"
+)
+
+// openDB connects to the database db_url names: sqlite:// followed by the
+// path of a database file, e.g. sqlite://~/.gator.db, or anything else
+// lib/pq accepts, a postgres:// URL or a "host=... dbname=..." string. The
+// migrator applies the schema for that engine.
+func openDB(dbURL string) (*sql.DB, database.Querier, *migrate.Migrator, error) {
+	if path, ok := strings.CutPrefix(dbURL, "sqlite://"); ok {
+		path, err := expandHome(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		db, err := sqlitedb.Open(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		migrator, err := migrate.New(db, migrate.SQLite, migrationsDir(sqliteMigrations, "sql/sqlite/schema"))
+		if err != nil {
+			db.Close()
+			return nil, nil, nil, err
+		}
+		return db, sqlitedb.NewStore(db), migrator, nil
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	migrator, err := migrate.New(db, migrate.Postgres, migrationsDir(postgresMigrations, "sql/schema"))
+	if err != nil {
+		db.Close()
+		return nil, nil, nil, err
+	}
+	return db, database.New(db), migrator, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
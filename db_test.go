@@ -0,0 +1,37 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/sqlitedb"
+)
+
+func TestOpenDB(t *testing.T) {
+	// lib/pq connects lazily, so no server is needed to pick the driver
+	for _, dbURL := range []string{
+		"postgres://gator:@localhost:5432/gator?sslmode=disable",
+		"postgresql://localhost/gator",
+		"host=localhost user=gator dbname=gator sslmode=disable",
+	} {
+		db, querier, _, err := openDB(dbURL)
+		if err != nil {
+			t.Errorf("openDB(%q): %v", dbURL, err)
+			continue
+		}
+		if _, ok := querier.(*database.Queries); !ok {
+			t.Errorf("openDB(%q) opened a %T", dbURL, querier)
+		}
+		db.Close()
+	}
+
+	db, querier, _, err := openDB("sqlite://" + filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, ok := querier.(*sqlitedb.Store); !ok {
+		t.Errorf("sqlite:// opened a %T", querier)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// feedLinkTypes are the <link rel="alternate"> types that point at feeds,
+// mapped to whether gator can read them.
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": false,
+}
+
+// commonFeedPaths are tried when a page doesn't advertise its feed.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.xml",
+	"/rss",
+}
+
+type discoveredFeed struct {
+	URL   string
+	Title string
+	Type  string
+	// Unsupported is set for feeds in a format gator can't read, such as
+	// JSON Feed.
+	Unsupported bool
+	// Feed is the parsed feed when discovery already downloaded it.
+	Feed *RSSFeed
+}
+
+// resolveFeed returns the feed for rawURL. rawURL itself is returned when it
+// already is a feed; otherwise the page is searched for feeds and the user is
+// asked to pick one when there are several.
+func (f *feedFetcher) resolveFeed(ctx context.Context, rawURL string, header http.Header, in io.Reader, out io.Writer) (discoveredFeed, error) {
+	feeds, err := f.discoverFeeds(ctx, rawURL, header)
+	if err != nil {
+		return discoveredFeed{}, err
+	}
+	switch len(feeds) {
+	case 0:
+		return discoveredFeed{}, fmt.Errorf("no feed found at %s", rawURL)
+	case 1:
+		if feeds[0].URL != rawURL {
+			fmt.Fprintf(out, "Found feed: %s\n", feeds[0].URL)
+		}
+		return feeds[0], nil
+	}
+	return chooseFeed(feeds, in, out)
+}
+
+// discoverFeeds fetches pageURL and returns the feeds it leads to: the page
+// itself when it parses as a feed, the feeds it links to, or, failing those,
+// any of commonFeedPaths that exist on the same host. Linked feeds gator
+// can't read are only mentioned in the error when nothing else is found.
+func (f *feedFetcher) discoverFeeds(ctx context.Context, pageURL string, header http.Header) ([]discoveredFeed, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	body, respHeader, err := f.fetchPageResponse(ctx, pageURL, header)
+	if err != nil {
+		return nil, err
+	}
+	if rssFeed, err := parsePageFeed(pageURL, body, respHeader); err == nil {
+		return []discoveredFeed{{URL: pageURL, Title: rssFeed.Channel.Title, Feed: rssFeed}}, nil
+	}
+
+	links, err := feedLinks(bytes.NewReader(body), base)
+	if err != nil {
+		return nil, err
+	}
+	var feeds, unsupported []discoveredFeed
+	for _, link := range links {
+		if link.Unsupported {
+			unsupported = append(unsupported, link)
+		} else {
+			feeds = append(feeds, link)
+		}
+	}
+	if len(feeds) > 0 {
+		return feeds, nil
+	}
+
+	for _, p := range commonFeedPaths {
+		candidate := base.ResolveReference(&url.URL{Path: p}).String()
+		body, respHeader, err := f.fetchPageResponse(ctx, candidate, header)
+		if err != nil {
+			continue
+		}
+		if rssFeed, err := parsePageFeed(candidate, body, respHeader); err == nil {
+			feeds = append(feeds, discoveredFeed{URL: candidate, Title: rssFeed.Channel.Title, Feed: rssFeed})
+		}
+	}
+	if len(feeds) == 0 && len(unsupported) > 0 {
+		var found []string
+		for _, feed := range unsupported {
+			found = append(found, fmt.Sprintf("%s (%s)", feed.URL, feed.Type))
+		}
+		return nil, fmt.Errorf("%s only links to feeds gator can't read: %s", pageURL, strings.Join(found, ", "))
+	}
+	return feeds, nil
+}
+
+// parsePageFeed parses a downloaded page as a feed, the same way fetchFeed
+// does.
+func parsePageFeed(pageURL string, dat []byte, header http.Header) (*RSSFeed, error) {
+	err := checkFeedContentType(pageURL, header.Get("Content-Type"), dat)
+	if err != nil {
+		return nil, err
+	}
+	dat, err = decodeCharset(dat, header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	return parseFeed(dat)
+}
+
+// feedLinks returns the feeds advertised by <link rel="alternate"> tags in an
+// HTML document, resolved against base.
+func feedLinks(r io.Reader, base *url.URL) ([]discoveredFeed, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var feeds []discoveredFeed
+	seen := map[string]bool{}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Link {
+			var rel, typ, href, title string
+			for _, a := range n.Attr {
+				switch a.Key {
+				case "rel":
+					rel = strings.ToLower(a.Val)
+				case "type":
+					typ = strings.ToLower(strings.TrimSpace(a.Val))
+				case "href":
+					href = strings.TrimSpace(a.Val)
+				case "title":
+					title = a.Val
+				}
+			}
+			supported, isFeed := feedLinkTypes[typ]
+			if href != "" && isFeed && hasToken(rel, "alternate") {
+				if ref, err := url.Parse(href); err == nil {
+					feedURL := base.ResolveReference(ref).String()
+					if !seen[feedURL] {
+						seen[feedURL] = true
+						feeds = append(feeds, discoveredFeed{URL: feedURL, Title: title, Type: typ, Unsupported: !supported})
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+	return feeds, nil
+}
+
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseFeed lists feeds on out and reads the user's choice from in.
+func chooseFeed(feeds []discoveredFeed, in io.Reader, out io.Writer) (discoveredFeed, error) {
+	fmt.Fprintln(out, "Multiple feeds found:")
+	for i, feed := range feeds {
+		label := feed.Title
+		if label == "" {
+			label = feed.Type
+		}
+		fmt.Fprintf(out, " %d) %s %s\n", i+1, feed.URL, label)
+	}
+	fmt.Fprintf(out, "Select a feed [1-%d]: ", len(feeds))
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return discoveredFeed{}, fmt.Errorf("no feed selected")
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(feeds) {
+		return discoveredFeed{}, fmt.Errorf("invalid selection %q", strings.TrimSpace(line))
+	}
+	return feeds[choice-1], nil
+}
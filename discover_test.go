@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/feedtest"
+)
+
+func htmlPage(head string) feedtest.Response {
+	return feedtest.Response{
+		Header: http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:   []byte(`<!DOCTYPE html><html><head>` + head + `</head><body>Blog</body></html>`),
+	}
+}
+
+func TestFeedLinks(t *testing.T) {
+	page := `<html><head>
+<link rel="stylesheet" href="/style.css">
+<link rel="alternate" type="application/rss+xml" title="Posts" href="feed.xml">
+<link rel="ALTERNATE" type="Application/Atom+XML" href="/atom.xml">
+<link rel="alternate" type="application/rss+xml" href="https://example.com/blog/feed.xml">
+<link rel="alternate" type="application/feed+json" href="//cdn.example.org/feed.json">
+<link rel="alternate" type="text/html" hreflang="de" href="/de/">
+<link rel="alternate" type="application/rss+xml" href="">
+<link rel="home" type="application/rss+xml" href="/not-alternate.xml">
+</head><body>
+<link rel="alternate" type="application/rss+xml" href="/comments/feed.xml">
+</body></html>`
+	base, _ := url.Parse("https://example.com/blog/")
+
+	feeds, err := feedLinks(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []discoveredFeed{
+		{URL: "https://example.com/blog/feed.xml", Title: "Posts", Type: "application/rss+xml"},
+		{URL: "https://example.com/atom.xml", Type: "application/atom+xml"},
+		{URL: "https://cdn.example.org/feed.json", Type: "application/feed+json", Unsupported: true},
+		{URL: "https://example.com/comments/feed.xml", Type: "application/rss+xml"},
+	}
+	if len(feeds) != len(want) {
+		t.Fatalf("feedLinks = %+v", feeds)
+	}
+	for i := range want {
+		if feeds[i] != want[i] {
+			t.Errorf("feed %d = %+v, want %+v", i, feeds[i], want[i])
+		}
+	}
+}
+
+func TestDiscoverFeeds(t *testing.T) {
+	server := feedtest.NewServer(t)
+	feedURL := server.Script("/feed.xml", feedtest.OK(feedtest.RSS("Posts")))
+	server.Script("/atom", feedtest.Atom(feedtest.Fixture("atom.xml")))
+	linked := server.Script("/blog/", htmlPage(`<link rel="alternate" type="application/rss+xml" title="Posts" href="../feed.xml">
+<link rel="alternate" type="application/atom+xml" title="Everything" href="/atom">`))
+	// Nothing advertised, so the common paths are tried
+	site := feedtest.NewServer(t)
+	site.Script("/rss.xml", feedtest.OK(feedtest.RSS("RSS")))
+	site.Script("/index.xml", htmlPage(""))
+	site.Script("/rss", feedtest.OK(feedtest.RSS("Also RSS")))
+	bare := site.Script("/about", htmlPage(""))
+	// Feeds whose charset is only in the header are decoded like fetchFeed does
+	latin1 := server.Script("/latin1.xml", feedtest.Response{
+		Header: http.Header{"Content-Type": {"text/xml; charset=ISO-8859-1"}},
+		Body:   []byte("<rss version=\"2.0\"><channel><title>Caf\xe9</title></channel></rss>"),
+	})
+
+	fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 1 << 20}
+	tests := []struct {
+		name   string
+		url    string
+		want   []string
+		titles []string
+		parsed bool
+	}{
+		{"feed itself", feedURL, []string{feedURL}, []string{"Posts"}, true},
+		{"linked feeds", linked, []string{feedURL, server.URL + "/atom"}, []string{"Posts", "Everything"}, false},
+		{"common paths", bare, []string{site.URL + "/rss.xml", site.URL + "/rss"}, []string{"RSS", "Also RSS"}, true},
+		{"header charset", latin1, []string{latin1}, []string{"Café"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feeds, err := fetcher.discoverFeeds(context.Background(), tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(feeds) != len(tt.want) {
+				t.Fatalf("discoverFeeds = %+v, want %v", feeds, tt.want)
+			}
+			for i, feed := range feeds {
+				if feed.URL != tt.want[i] || feed.Title != tt.titles[i] {
+					t.Errorf("feed %d = %s %q, want %s %q", i, feed.URL, feed.Title, tt.want[i], tt.titles[i])
+				}
+				if (feed.Feed != nil) != tt.parsed {
+					t.Errorf("feed %d parsed = %v, want %v", i, feed.Feed != nil, tt.parsed)
+				}
+			}
+		})
+	}
+
+	nothing := feedtest.NewServer(t).Script("/", htmlPage(""))
+	_, err := fetcher.resolveFeed(context.Background(), nothing, nil, strings.NewReader(""), &strings.Builder{})
+	if err == nil || !strings.Contains(err.Error(), "no feed found") {
+		t.Errorf("resolveFeed of a page without feeds: %v", err)
+	}
+}
+
+func TestChooseFeed(t *testing.T) {
+	feeds := []discoveredFeed{
+		{URL: "https://example.com/feed.xml", Title: "Posts", Type: "application/rss+xml"},
+		{URL: "https://example.com/atom.xml", Type: "application/atom+xml"},
+	}
+	tests := []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{"1\n", "https://example.com/feed.xml", ""},
+		{" 2 \n", "https://example.com/atom.xml", ""},
+		{"2", "https://example.com/atom.xml", ""},
+		{"3\n", "", "invalid selection"},
+		{"0\n", "", "invalid selection"},
+		{"atom\n", "", "invalid selection"},
+		{"", "", "no feed selected"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		feed, err := chooseFeed(feeds, strings.NewReader(tt.input), &out)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("chooseFeed(%q) = %v, want %q", tt.input, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || feed.URL != tt.want {
+			t.Errorf("chooseFeed(%q) = %s, %v, want %s", tt.input, feed.URL, err, tt.want)
+		}
+		// Feeds without a title are labelled with their type
+		listing := out.String()
+		if !strings.Contains(listing, " 1) https://example.com/feed.xml Posts") ||
+			!strings.Contains(listing, " 2) https://example.com/atom.xml application/atom+xml") ||
+			!strings.Contains(listing, "Select a feed [1-2]") {
+			t.Errorf("chooseFeed printed:\n%s", listing)
+		}
+	}
+}
+
+func TestAddFeedDownloadsOnce(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	feedURL := server.Script("/feed.xml", testFeed(server, "First"))
+	page := server.Script("/blog", htmlPage(`<link rel="alternate" type="application/rss+xml" href="/other.xml">`))
+	server.Script("/other.xml", feedtest.OK(feedtest.RSS("Other", feedtest.Item{
+		Title:     "Elsewhere",
+		Link:      server.URL + "/elsewhere",
+		Published: time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC),
+	})))
+
+	mustRun(t, s, "register", "alice")
+	mustRun(t, s, "addfeed", feedURL)
+	if got := len(server.Requests("/feed.xml")); got != 1 {
+		t.Errorf("addfeed of a feed downloaded it %d times", got)
+	}
+
+	mustRun(t, s, "addfeed", "Other", page)
+	if got := len(server.Requests("/blog")); got != 1 {
+		t.Errorf("addfeed downloaded the page %d times", got)
+	}
+	if got := len(server.Requests("/other.xml")); got != 1 {
+		t.Errorf("addfeed downloaded the linked feed %d times", got)
+	}
+	posts, _ := s.db.GetPosts(context.Background())
+	if len(posts) != 2 {
+		t.Errorf("%d posts imported, want 2", len(posts))
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type MediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type MediaGroup struct {
+	Content []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+// Enclosure is an attachment of an item, such as a podcast episode's audio.
+type Enclosure struct {
+	URL      string
+	Type     string
+	Length   int64
+	Duration int
+}
+
+// enclosures merges <enclosure>, media:content and media:group entries of
+// item into one list without duplicate URLs. The iTunes duration applies to
+// enclosures that don't state their own.
+func (item RSSItem) enclosures() []Enclosure {
+	var result []Enclosure
+	seen := map[string]bool{}
+	add := func(e Enclosure) {
+		e.URL = strings.TrimSpace(e.URL)
+		if e.URL == "" || seen[e.URL] {
+			return
+		}
+		seen[e.URL] = true
+		result = append(result, e)
+	}
+
+	itunesDuration := parseDuration(item.ITunesDuration)
+	for _, enc := range item.Enclosures {
+		add(Enclosure{
+			URL:      enc.URL,
+			Type:     enc.Type,
+			Length:   parseLength(enc.Length),
+			Duration: itunesDuration,
+		})
+	}
+
+	media := item.MediaContent
+	for _, group := range item.MediaGroups {
+		media = append(media, group.Content...)
+	}
+	for _, content := range media {
+		duration := parseDuration(content.Duration)
+		if duration == 0 {
+			duration = itunesDuration
+		}
+		add(Enclosure{
+			URL:      content.URL,
+			Type:     content.Type,
+			Length:   parseLength(content.FileSize),
+			Duration: duration,
+		})
+	}
+
+	return result
+}
+
+func parseLength(s string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// parseDuration reads durations as seconds, MM:SS or HH:MM:SS and returns
+// them in seconds, or 0 when unparsable.
+func parseDuration(s string) int {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	seconds := 0
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + int(n)
+	}
+	return seconds
+}
+
+func formatDuration(seconds int32) string {
+	h := seconds / 3600
+	m := seconds % 3600 / 60
+	sec := seconds % 60
+	if h > 0 {
+		return strconv.Itoa(int(h)) + ":" + twoDigits(m) + ":" + twoDigits(sec)
+	}
+	return strconv.Itoa(int(m)) + ":" + twoDigits(sec)
+}
+
+func twoDigits(n int32) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(int(n))
+	}
+	return strconv.Itoa(int(n))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/urlnorm"
+)
+
+// Kinds of feed events shown to a feed's followers.
+const (
+	feedEventRedirect = "redirect"
+	feedEventMoved    = "moved"
+	feedEventGone     = "gone"
+	feedEventEnabled  = "enabled"
+	feedEventWebSub   = "websub"
+)
+
+func recordFeedEvent(s *state, feedID uuid.UUID, kind, message string) {
+	err := s.db.CreateFeedEvent(context.Background(), database.CreateFeedEventParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		FeedID:    feedID,
+		Kind:      kind,
+		Message:   message,
+	})
+	if err != nil {
+		slog.Error("couldn't record feed event", "feed_id", feedID, "kind", kind, "error", err)
+	}
+}
+
+// disableGoneFeed stops fetching a feed whose server answered 410 Gone.
+func disableGoneFeed(s *state, feed database.Feed) {
+	err := s.db.SetFeedDisabled(context.Background(), database.SetFeedDisabledParams{
+		DisabledAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		UpdatedAt:  time.Now().UTC(),
+		ID:         feed.ID,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't disable feed", "error", err)
+		return
+	}
+	recordFeedEvent(s, feed.ID, feedEventGone, fmt.Sprintf("%s returned 410 Gone, the feed is no longer fetched", feed.Url))
+}
+
+// trackRedirect counts consecutive fetches of feed that permanently
+// redirected to target and moves the feed to target once the configured
+// threshold is reached. An empty target means the fetch wasn't redirected.
+func trackRedirect(s *state, feed database.Feed, target string) {
+	if target == "" {
+		if feed.RedirectCount > 0 {
+			updateRedirect(s, feed, "", 0)
+		}
+		return
+	}
+
+	if normalized, err := urlnorm.Normalize(target); err == nil {
+		target = normalized
+	}
+	if target == feed.Url {
+		return
+	}
+
+	count := 1
+	if feed.RedirectUrl == target {
+		count = int(feed.RedirectCount) + 1
+	}
+	threshold := s.cfg.PermanentRedirectThreshold()
+
+	if count == 1 {
+		recordFeedEvent(s, feed.ID, feedEventRedirect, fmt.Sprintf("%s permanently redirects to %s", feed.Url, target))
+	}
+	if count < threshold {
+		updateRedirect(s, feed, target, count)
+		return
+	}
+
+	if existing, err := lookupFeedURL(s, target); err == nil {
+		if count == threshold {
+			recordFeedEvent(s, feed.ID, feedEventRedirect, fmt.Sprintf("%s redirects to %s, which is already tracked as %s; URL left unchanged", feed.Url, target, existing.Name))
+		}
+		updateRedirect(s, feed, target, count)
+		return
+	}
+
+	err := s.db.UpdateFeedURL(context.Background(), database.UpdateFeedURLParams{
+		Url:       target,
+		UpdatedAt: time.Now().UTC(),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't update feed URL", "new_url", target, "error", err)
+		return
+	}
+	recordFeedEvent(s, feed.ID, feedEventMoved, fmt.Sprintf("URL changed from %s to %s after %d permanent redirects", feed.Url, target, count))
+}
+
+func updateRedirect(s *state, feed database.Feed, target string, count int) {
+	err := s.db.RecordFeedRedirect(context.Background(), database.RecordFeedRedirectParams{
+		RedirectUrl:   target,
+		RedirectCount: int32(count),
+		UpdatedAt:     time.Now().UTC(),
+		ID:            feed.ID,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't record redirect", "redirect_url", target, "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/urlnorm"
+)
+
+// findFeed resolves a feed given on the command line by its ID, URL or name.
+func findFeed(s *state, ref string) (database.Feed, error) {
+	ref = strings.TrimSpace(ref)
+
+	if id, err := uuid.Parse(ref); err == nil {
+		feed, err := s.db.GetFeed(context.Background(), id)
+		if err == nil {
+			return feed, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, err
+		}
+	}
+
+	if strings.Contains(ref, "/") {
+		feed, err := lookupFeedURL(s, ref)
+		if err == nil {
+			return feed, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, err
+		}
+	}
+
+	feeds, err := s.db.GetFeedsByName(context.Background(), ref)
+	if err != nil {
+		return database.Feed{}, err
+	}
+	switch len(feeds) {
+	case 0:
+		return database.Feed{}, fmt.Errorf("Unable to find existing feed %s", ref)
+	case 1:
+		return feeds[0], nil
+	}
+
+	var urls []string
+	for _, feed := range feeds {
+		urls = append(urls, feed.Url)
+	}
+	return database.Feed{}, fmt.Errorf("%d feeds are named %s, use the URL or ID instead: %s", len(feeds), ref, strings.Join(urls, ", "))
+}
+
+// lookupFeedURL finds a stored feed by URL. Both the URL as given and its
+// normalized form are tried, the latter with either http or https.
+func lookupFeedURL(s *state, rawURL string) (database.Feed, error) {
+	candidates := []string{strings.TrimSpace(rawURL)}
+	if normalized, err := urlnorm.Normalize(rawURL); err == nil {
+		candidates = append(candidates, normalized, urlnorm.WithOtherScheme(normalized))
+	}
+
+	for _, candidate := range candidates {
+		feed, err := s.db.GetFeedByURL(context.Background(), candidate)
+		if err == nil {
+			return feed, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Feed{}, err
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
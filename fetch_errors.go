@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// statusError is returned for responses other than 200 OK.
+type statusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status fetching %s: %s", e.URL, e.Status)
+}
+
+// bodyTooLargeError is returned when a response exceeds the configured
+// maximum size, before or after decompression.
+type bodyTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *bodyTooLargeError) Error() string {
+	return fmt.Sprintf("response from %s is larger than %d bytes", e.URL, e.Limit)
+}
+
+// encodingError is returned when a response uses a Content-Encoding gator
+// can't decode, or the compressed data is corrupt.
+type encodingError struct {
+	URL      string
+	Encoding string
+	Err      error
+}
+
+func (e *encodingError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("unsupported content encoding %q from %s", e.Encoding, e.URL)
+	}
+	return fmt.Sprintf("couldn't decode %s response from %s: %v", e.Encoding, e.URL, e.Err)
+}
+
+func (e *encodingError) Unwrap() error {
+	return e.Err
+}
+
+// notFeedError is returned when a response is clearly not a feed, such as
+// an HTML page or an image.
+type notFeedError struct {
+	URL         string
+	ContentType string
+}
+
+func (e *notFeedError) Error() string {
+	return fmt.Sprintf("%s is not a feed (content type %s)", e.URL, e.ContentType)
+}
+
+// parseError is returned when a response looks like a feed but can't be
+// parsed.
+type parseError struct {
+	URL string
+	Err error
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("couldn't parse feed %s: %v", e.URL, e.Err)
+}
+
+func (e *parseError) Unwrap() error {
+	return e.Err
+}
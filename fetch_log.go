@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+// fetchRun is what a single fetch of a feed did, as kept in the fetch log.
+type fetchRun struct {
+	FeedID     uuid.UUID
+	StartedAt  time.Time
+	StatusCode int
+	Bytes      int64
+	Items      feedItemStats
+}
+
+func recordFetch(s *state, run fetchRun, fetchErr error) {
+	message := ""
+	if fetchErr != nil {
+		message = fetchErr.Error()
+	}
+	err := s.db.CreateFetchLog(context.Background(), database.CreateFetchLogParams{
+		ID:           uuid.New(),
+		FeedID:       run.FeedID,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   time.Now().UTC(),
+		StatusCode:   int32(run.StatusCode),
+		Bytes:        run.Bytes,
+		ItemsSeen:    int32(run.Items.Seen),
+		ItemsNew:     int32(run.Items.New),
+		ItemsUpdated: int32(run.Items.Updated),
+		Error:        message,
+	})
+	if err != nil {
+		slog.Error("couldn't record fetch", "feed_id", run.FeedID, "error", err)
+	}
+}
+
+// trimFetchLog deletes fetch log rows older than the configured retention.
+func trimFetchLog(s *state) {
+	_, err := s.db.DeleteFetchLogBefore(context.Background(), time.Now().UTC().Add(-s.cfg.FetchLogMaxAge()))
+	if err != nil {
+		slog.Error("couldn't trim fetch log", "error", err)
+	}
+}
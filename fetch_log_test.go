@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/config"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func logFetch(t *testing.T, s *state, feed database.Feed, started time.Time, entry database.CreateFetchLogParams) {
+	t.Helper()
+	entry.ID = uuid.New()
+	entry.FeedID = feed.ID
+	entry.StartedAt = started
+	if entry.FinishedAt.IsZero() {
+		entry.FinishedAt = started
+	}
+	if err := s.db.CreateFetchLog(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchLogCommand(t *testing.T) {
+	s := newTestState(t)
+	out := mustRun(t, s, "fetchlog")
+	if !strings.Contains(out, "No fetches in that period") {
+		t.Errorf("fetchlog with an empty log printed:\n%s", out)
+	}
+
+	blog := addTestFeed(t, s, "Blog", "https://example.com/feed.xml")
+	news := addTestFeed(t, s, "News", "https://example.org/rss")
+	now := time.Now().UTC()
+	logFetch(t, s, blog, now.Add(-time.Hour), database.CreateFetchLogParams{
+		FinishedAt:   now.Add(-time.Hour + 300*time.Millisecond),
+		StatusCode:   200,
+		Bytes:        1500,
+		ItemsSeen:    10,
+		ItemsNew:     3,
+		ItemsUpdated: 1,
+	})
+	logFetch(t, s, news, now.Add(-30*time.Minute), database.CreateFetchLogParams{
+		FinishedAt: now.Add(-30*time.Minute + 100*time.Millisecond),
+		Error:      "connection refused",
+	})
+	logFetch(t, s, blog, now.Add(-48*time.Hour), database.CreateFetchLogParams{
+		StatusCode: 304,
+	})
+
+	out = mustRun(t, s, "fetchlog")
+	for _, want := range []string{
+		"Blog (https://example.com/feed.xml) 200 OK, 300ms",
+		"    1500 bytes, 10 items, 3 new, 1 updated",
+		"News (https://example.org/rss) -, 100ms",
+		"    error: connection refused",
+		"Fetches: 2 (1 failed), average 200ms",
+		"Items:   10 seen, 3 new, 1 updated",
+		"Bytes:   1500",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("fetchlog output is missing %q:\n%s", want, out)
+		}
+	}
+	// Newest first, and the day-old fetch is left out by default
+	if strings.Index(out, "News") > strings.Index(out, "Blog") || strings.Contains(out, "304") {
+		t.Errorf("fetchlog printed:\n%s", out)
+	}
+
+	out = mustRun(t, s, "fetchlog", "--feed", "blog", "--since", "72h")
+	if strings.Contains(out, "News") || !strings.Contains(out, "304 Not Modified") || !strings.Contains(out, "Fetches: 2 (0 failed)") {
+		t.Errorf("fetchlog --feed blog --since 72h printed:\n%s", out)
+	}
+
+	for _, args := range [][]string{
+		{"--since", "yesterday"},
+		{"--since", "-1h"},
+		{"--since"},
+		{"--feed", "No Such Feed"},
+		{"--verbose", "yes"},
+	} {
+		if _, err := run(t, s, "fetchlog", args...); err == nil {
+			t.Errorf("fetchlog %v worked", args)
+		}
+	}
+}
+
+func TestTrimFetchLog(t *testing.T) {
+	s := newTestStateWithConfig(t, &config.Config{HostRequestDelay: "0s", FetchLogRetention: "1h"})
+	feed := addTestFeed(t, s, "Blog", "https://example.com/feed.xml")
+	now := time.Now().UTC()
+	for _, age := range []time.Duration{2 * time.Hour, 61 * time.Minute, 59 * time.Minute, time.Minute} {
+		logFetch(t, s, feed, now.Add(-age), database.CreateFetchLogParams{StatusCode: 200})
+	}
+
+	trimFetchLog(s)
+	log, err := s.db.GetFetchLog(context.Background(), database.GetFetchLogParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 || !log[1].StartedAt.Equal(now.Add(-59*time.Minute)) {
+		t.Errorf("fetch log after trimming to 1h = %+v", log)
+	}
+}
+
+func TestDeleteFetchLogBeforeBoundary(t *testing.T) {
+	s := newTestState(t)
+	feed := addTestFeed(t, s, "Blog", "https://example.com/feed.xml")
+	cutoff := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	logFetch(t, s, feed, cutoff.Add(-time.Microsecond), database.CreateFetchLogParams{})
+	logFetch(t, s, feed, cutoff, database.CreateFetchLogParams{})
+
+	deleted, err := s.db.DeleteFetchLogBefore(context.Background(), cutoff)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteFetchLogBefore = %d, %v", deleted, err)
+	}
+	log, _ := s.db.GetFetchLog(context.Background(), database.GetFetchLogParams{})
+	if len(log) != 1 || !log[0].StartedAt.Equal(cutoff) {
+		t.Errorf("a fetch started exactly at the cutoff wasn't kept: %+v", log)
+	}
+}
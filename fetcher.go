@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/mortalglitch/gator/internal/config"
+)
+
+const acceptFeeds = "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.9, */*;q=0.8"
+
+// feedFetcher downloads feeds and web pages within the limits set in the
+// config.
+type feedFetcher struct {
+	client      *http.Client
+	maxBodySize int64
+}
+
+// feedResponse is a fetched and parsed feed along with what the fetch
+// revealed about the feed's URL.
+type feedResponse struct {
+	Feed *RSSFeed
+	// PermanentURL is the URL the feed was served from when every redirect
+	// on the way there was permanent (301 or 308), empty otherwise.
+	PermanentURL string
+	Header       http.Header
+	StatusCode   int
+	// Bytes is the size of the feed after decompression.
+	Bytes int64
+}
+
+func newFeedFetcher(cfg *config.Config) (*feedFetcher, error) {
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &feedFetcher{
+		client:      client,
+		maxBodySize: cfg.MaxFeedBytes(),
+	}, nil
+}
+
+// newRequest builds a GET request for rawURL carrying the extra headers,
+// such as credentials, stored for a feed.
+func newRequest(ctx context.Context, rawURL string, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return req, nil
+}
+
+// clientFor returns a client for requests carrying a feed's extra headers,
+// which drops them on redirects to another host.
+func (f *feedFetcher) clientFor(header http.Header) *http.Client {
+	httpClient := *f.client
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		dropHeadersOffHost(header, req, via)
+		return nil
+	}
+	return &httpClient
+}
+
+// sameHost reports whether two URLs point at the same host and port.
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Host, ub.Host)
+}
+
+// dropHeadersOffHost removes a feed's extra headers from a redirect to
+// another host, so its credentials are only ever sent where they were
+// configured.
+func dropHeadersOffHost(header http.Header, req *http.Request, via []*http.Request) {
+	if len(via) == 0 || strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		return
+	}
+	for name := range header {
+		req.Header.Del(name)
+	}
+}
+
+func (f *feedFetcher) fetchFeed(ctx context.Context, feedURL string, header http.Header) (*RSSFeed, error) {
+	response, err := f.fetchFeedResponse(ctx, feedURL, header)
+	if err != nil {
+		return nil, err
+	}
+	return response.Feed, nil
+}
+
+func (f *feedFetcher) fetchFeedResponse(ctx context.Context, feedURL string, header http.Header) (*feedResponse, error) {
+	redirected := false
+	permanent := true
+	httpClient := *f.client
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		dropHeadersOffHost(header, req, via)
+		redirected = true
+		code := req.Response.StatusCode
+		if code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+			permanent = false
+		}
+		return nil
+	}
+	req, err := newRequest(ctx, feedURL, header)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", acceptFeeds)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{URL: feedURL, StatusCode: resp.StatusCode, Status: resp.Status, Header: resp.Header}
+	}
+
+	dat, err := f.readBody(feedURL, resp)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(len(dat))
+	err = checkFeedContentType(feedURL, resp.Header.Get("Content-Type"), dat)
+	if err != nil {
+		return nil, err
+	}
+
+	dat, err = decodeCharset(dat, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, &parseError{URL: feedURL, Err: err}
+	}
+	rssFeed, err := parseFeed(dat)
+	if err != nil {
+		return nil, &parseError{URL: feedURL, Err: err}
+	}
+
+	response := &feedResponse{
+		Feed:       rssFeed,
+		Header:     resp.Header,
+		StatusCode: resp.StatusCode,
+		Bytes:      size,
+	}
+	if redirected && permanent {
+		response.PermanentURL = resp.Request.URL.String()
+	}
+	return response, nil
+}
+
+// fetchPage downloads a web page, used when looking for a site's feeds.
+func (f *feedFetcher) fetchPage(ctx context.Context, pageURL string, header http.Header) ([]byte, error) {
+	dat, _, err := f.fetchPageResponse(ctx, pageURL, header)
+	return dat, err
+}
+
+// fetchPageResponse is fetchPage that also returns the response headers.
+func (f *feedFetcher) fetchPageResponse(ctx context.Context, pageURL string, header http.Header) ([]byte, http.Header, error) {
+	req, err := newRequest(ctx, pageURL, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	resp, err := f.clientFor(header).Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, &statusError{URL: pageURL, StatusCode: resp.StatusCode, Status: resp.Status, Header: resp.Header}
+	}
+	dat, err := f.readBody(pageURL, resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dat, resp.Header, nil
+}
+
+// readBody decodes resp's body according to its Content-Encoding and reads
+// at most maxBodySize bytes of it.
+func (f *feedFetcher) readBody(rawURL string, resp *http.Response) ([]byte, error) {
+	if resp.ContentLength > f.maxBodySize {
+		return nil, &bodyTooLargeError{URL: rawURL, Limit: f.maxBodySize}
+	}
+
+	var body io.Reader = io.LimitReader(resp.Body, f.maxBodySize+1)
+	encodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+	// Encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		decoded, err := decodeBody(body, encoding)
+		if err != nil {
+			return nil, &encodingError{URL: rawURL, Encoding: encoding, Err: err}
+		}
+		if decoded == nil {
+			return nil, &encodingError{URL: rawURL, Encoding: encoding}
+		}
+		body = decoded
+	}
+
+	dat, err := io.ReadAll(io.LimitReader(body, f.maxBodySize+1))
+	if err != nil {
+		if resp.Header.Get("Content-Encoding") != "" {
+			return nil, &encodingError{URL: rawURL, Encoding: resp.Header.Get("Content-Encoding"), Err: err}
+		}
+		return nil, err
+	}
+	if int64(len(dat)) > f.maxBodySize {
+		return nil, &bodyTooLargeError{URL: rawURL, Limit: f.maxBodySize}
+	}
+	return dat, nil
+}
+
+// decodeBody wraps r in a decoder for encoding. It returns a nil reader for
+// encodings it doesn't know.
+func decodeBody(r io.Reader, encoding string) (io.Reader, error) {
+	switch encoding {
+	case "", "identity":
+		return r, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "br":
+		return brotli.NewReader(r), nil
+	case "deflate":
+		// deflate is meant to be zlib-wrapped, but some servers send raw
+		// deflate data
+		buffered := bufio.NewReader(r)
+		header, _ := buffered.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(buffered)
+		}
+		return flate.NewReader(buffered), nil
+	}
+	return nil, nil
+}
+
+// nonFeedTypes are content types that are never feeds.
+var nonFeedTypes = []string{
+	"image/",
+	"audio/",
+	"video/",
+	"font/",
+	"application/pdf",
+	"application/zip",
+	"application/gzip",
+	"application/javascript",
+	"text/css",
+	"text/javascript",
+}
+
+// checkFeedContentType rejects responses that are obviously not feeds,
+// looking at the declared Content-Type and at the start of the body, since
+// many servers send feeds with generic or wrong types.
+func checkFeedContentType(rawURL, contentType string, dat []byte) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+	for _, prefix := range nonFeedTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return &notFeedError{URL: rawURL, ContentType: mediaType}
+		}
+	}
+
+	head := bytes.ToLower(bytes.TrimSpace(dat[:min(len(dat), 512)]))
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html")) {
+		return &notFeedError{URL: rawURL, ContentType: "text/html"}
+	}
+
+	sniffed := http.DetectContentType(dat)
+	for _, prefix := range nonFeedTypes {
+		if strings.HasPrefix(sniffed, prefix) {
+			return &notFeedError{URL: rawURL, ContentType: sniffed}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+const fetcherTestFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Compressed</title>
+<item><title>First</title><link>https://example.com/1</link><pubDate>Mon, 01 Jan 2024 00:00:00 GMT</pubDate></item>
+</channel></rss>`
+
+func compress(t *testing.T, encoding string, dat []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown encoding %s", encoding)
+	}
+	if _, err := w.Write(dat); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func serveFeed(t *testing.T, contentType, contentEncoding string, body []byte) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip, deflate, br" {
+			t.Errorf("Accept-Encoding = %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", contentType)
+		if contentEncoding != "" {
+			w.Header().Set("Content-Encoding", contentEncoding)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestFetchFeedDecompresses(t *testing.T) {
+	feed := []byte(fetcherTestFeed)
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{"identity", "", feed},
+		{"gzip", "gzip", compress(t, "gzip", feed)},
+		{"x-gzip", "x-gzip", compress(t, "gzip", feed)},
+		{"zlib deflate", "deflate", compress(t, "deflate", feed)},
+		{"raw deflate", "deflate", compress(t, "raw deflate", feed)},
+		{"brotli", "br", compress(t, "br", feed)},
+		{"gzip then brotli", "gzip, br", compress(t, "br", compress(t, "gzip", feed))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedURL := serveFeed(t, "application/rss+xml", tt.encoding, tt.body)
+			fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 1 << 20}
+			response, err := fetcher.fetchFeedResponse(context.Background(), feedURL, nil)
+			if err != nil {
+				t.Fatalf("fetchFeedResponse: %v", err)
+			}
+			if response.Feed.Channel.Title != "Compressed" || len(response.Feed.Channel.Item) != 1 {
+				t.Errorf("parsed %q with %d items", response.Feed.Channel.Title, len(response.Feed.Channel.Item))
+			}
+			if response.Bytes != int64(len(feed)) {
+				t.Errorf("Bytes = %d, want the decompressed size %d", response.Bytes, len(feed))
+			}
+		})
+	}
+}
+
+func TestFetchFeedRejectsBadEncodings(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{"unknown encoding", "compress", []byte(fetcherTestFeed)},
+		{"corrupt gzip", "gzip", []byte("not gzip at all")},
+		{"truncated brotli", "br", compress(t, "br", []byte(fetcherTestFeed))[:20]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedURL := serveFeed(t, "application/rss+xml", tt.encoding, tt.body)
+			fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 1 << 20}
+			_, err := fetcher.fetchFeed(context.Background(), feedURL, nil)
+			var encErr *encodingError
+			if !errors.As(err, &encErr) {
+				t.Fatalf("fetchFeed = %v, want an encodingError", err)
+			}
+		})
+	}
+}
+
+func TestFetchFeedLimitsDecompressedSize(t *testing.T) {
+	// A small gzip body that expands past the limit
+	body := compress(t, "gzip", bytes.Repeat([]byte(" "), 64<<10))
+	feedURL := serveFeed(t, "application/rss+xml", "gzip", body)
+	fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 16 << 10}
+	_, err := fetcher.fetchFeed(context.Background(), feedURL, nil)
+	var tooLarge *bodyTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("fetchFeed = %v, want a bodyTooLargeError", err)
+	}
+}
+
+func TestFetchFeedRejectsNonFeeds(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01")
+	html := []byte("<!DOCTYPE html>\n<html><head><title>Home</title></head><body>Hi</body></html>")
+	tests := []struct {
+		name        string
+		contentType string
+		encoding    string
+		body        []byte
+		want        string
+	}{
+		{"image", "image/png", "", png, "image/png"},
+		{"unlabelled image", "application/octet-stream", "", png, "image/png"},
+		{"pdf", "application/pdf", "", []byte("%PDF-1.4"), "application/pdf"},
+		{"html page", "text/html; charset=utf-8", "", html, "text/html"},
+		{"html labelled as a feed", "application/rss+xml", "", html, "text/html"},
+		{"compressed html", "text/html", "gzip", compress(t, "gzip", html), "text/html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedURL := serveFeed(t, tt.contentType, tt.encoding, tt.body)
+			fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 1 << 20}
+			_, err := fetcher.fetchFeed(context.Background(), feedURL, nil)
+			var notFeed *notFeedError
+			if !errors.As(err, &notFeed) {
+				t.Fatalf("fetchFeed = %v, want a notFeedError", err)
+			}
+			if notFeed.ContentType != tt.want {
+				t.Errorf("content type = %s, want %s", notFeed.ContentType, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchFeedAcceptsMislabelledFeeds(t *testing.T) {
+	for _, contentType := range []string{"text/html", "text/plain", "application/octet-stream", ""} {
+		t.Run(contentType, func(t *testing.T) {
+			feedURL := serveFeed(t, contentType, "", []byte(fetcherTestFeed))
+			fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 1 << 20}
+			rssFeed, err := fetcher.fetchFeed(context.Background(), feedURL, nil)
+			if err != nil {
+				t.Fatalf("fetchFeed: %v", err)
+			}
+			if rssFeed.Channel.Title != "Compressed" {
+				t.Errorf("channel title = %q", rssFeed.Channel.Title)
+			}
+		})
+	}
+}
+
+func TestFetchFeedDecodesCharsetAfterDecompressing(t *testing.T) {
+	latin1 := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		"<rss version=\"2.0\"><channel><title>Caf\xe9</title>" +
+		"<item><title>D\xe9j\xe0 vu</title><link>https://example.com/1</link></item>" +
+		"</channel></rss>")
+	feedURL := serveFeed(t, "application/rss+xml", "gzip", compress(t, "gzip", latin1))
+	fetcher := &feedFetcher{client: http.DefaultClient, maxBodySize: 1 << 20}
+	rssFeed, err := fetcher.fetchFeed(context.Background(), feedURL, nil)
+	if err != nil {
+		t.Fatalf("fetchFeed: %v", err)
+	}
+	if rssFeed.Channel.Title != "Café" || rssFeed.Channel.Item[0].Title != "Déjà vu" {
+		t.Errorf("decoded %q / %q", rssFeed.Channel.Title, rssFeed.Channel.Item[0].Title)
+	}
+}
@@ -2,17 +2,41 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
 	"time"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/urlnorm"
 	"github.com/google/uuid"
 )
 
 func handlerAgg(s *state, cmd command) error {
 	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %v <time_between_reqs (1s, 1m, 1h)>", cmd.Name)
+		return fmt.Errorf("usage: %v <time_between_reqs (1s, 1m, 1h)> | --once", cmd.Name)
+	}
+
+	stopMetrics, err := startMetricsServer(s)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	if cmd.Args[0] == "--once" {
+		failed, err := scrapeAllDueFeeds(s)
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed fetches: %d, see gator fetchlog", failed)
+		}
+		return nil
 	}
 
 	timeBetweenReqs, err := time.ParseDuration(cmd.Args[0])
@@ -20,57 +44,127 @@ func handlerAgg(s *state, cmd command) error {
 		return fmt.Errorf("Error parsing time duration: %v", err)
 	}
 
-	ticker := time.NewTicker(timeBetweenReqs)
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
-	}
+	var current atomic.Pointer[state]
+	current.Store(s)
+	runDaemon(context.Background(), &current, timeBetweenReqs, func(s *state) {
+		err := scrapeFeeds(s)
+		if err != nil {
+			slog.Error("aggregation failed", "error", err)
+		}
+	})
 	return nil
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
 	
-	if len(cmd.Args) != 2 {
-		return fmt.Errorf("usage: %v <title> <url>", cmd.Name)
+	usage := fmt.Errorf("usage: %v [--basic user:password] [--bearer token] [--header \"Name: value\"] [title] <url>", cmd.Name)
+	header, args, err := parseCredentialFlags(cmd.Args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return usage
 	}
 
-	name := cmd.Args[0]
-	url := cmd.Args[1]
+	name := ""
+	rawURL := args[0]
+	if len(args) == 2 {
+		name = args[0]
+		rawURL = args[1]
+	}
+
+	// Someone may already have added this feed, in which case just follow it
+	if existing, err := lookupFeedURL(s, rawURL); err == nil {
+		return followExistingFeed(s, user, existing, header)
+	}
+
+	found, err := s.fetcher.resolveFeed(context.Background(), rawURL, header, os.Stdin, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	url, err := urlnorm.Normalize(found.URL)
+	if err != nil {
+		return fmt.Errorf("invalid feed URL: %w", err)
+	}
+	if existing, err := lookupFeedURL(s, url); err == nil {
+		return followExistingFeed(s, user, existing, header)
+	}
+
+	// Make sure the feed can actually be fetched and parsed before storing
+	// it, unless discovery already did
+	rssFeed := found.Feed
+	if rssFeed == nil {
+		rssFeed, err = s.fetcher.fetchFeed(context.Background(), url, header)
+		if err != nil {
+			return fmt.Errorf("couldn't fetch feed %s: %w", url, err)
+		}
+	}
+	if name == "" {
+		name = rssFeed.Channel.Title
+	}
+	if name == "" {
+		return fmt.Errorf("feed %s has no title, please provide one: %v <title> <url>", url, cmd.Name)
+	}
 	
 	feed, err := s.db.AddFeed(context.Background(), database.AddFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name:      name,
-		Url:       url,
-		UserID:    user.ID,
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+		Name:        name,
+		Url:         url,
+		UserID:      user.ID,
+		Link:        rssFeed.Channel.Link,
+		Description: rssFeed.Channel.Description,
 	})
 	if err != nil {
 		return fmt.Errorf("couldn't add feed: %w", err)
 	}
 	
+	err = saveFeedHeaders(s, feed.ID, header)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Feed added successfully:")
 	printFeed(feed, s)
 
-	// Register as following for current user
-	follow , err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
-		ID:    uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		UserID:    user.ID,
-		FeedID:    feed.ID,
+	// Ingest what the feed already has so it's browsable right away
+	err = s.db.MarkFeedFetched(context.Background(), database.MarkFeedFetchedParams{
+		LastFetchedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:            feed.ID,
 	})
 	if err != nil {
-		return fmt.Errorf("couldn't add follow: %w", err)
+		return fmt.Errorf("couldn't mark feed as fetched: %w", err)
 	}
-	fmt.Println("Added to follow list: ", follow.ID)
+	_, err = ingestFeed(s, feed, rssFeed)
+	if err != nil {
+		fmt.Printf("Unable to import initial posts: %v\n", err)
+	}
+	scheduleFeed(s, feed, feedScheduleHints(rssFeed, nil))
 
-	return nil	
+	// Register as following for current user
+	return followFeed(s, user, feed)
 }
 
 func printFeed(feed database.Feed, s *state) {
 	fmt.Printf(" * ID:      %v\n", feed.ID)
 	fmt.Printf(" * Name:    %v\n", feed.Name)
 	fmt.Printf(" * URL:     %v\n", feed.Url)
+	if feed.Link != "" {
+		fmt.Printf(" * Link:    %v\n", feed.Link)
+	}
+	if feed.Description != "" {
+		fmt.Printf(" * About:   %v\n", feed.Description)
+	}
+	if feed.DisabledAt.Valid {
+		fmt.Printf(" * Disabled since %v\n", feed.DisabledAt.Time.Format("2006-01-02"))
+	}
+	if feed.ParsedWithFixes {
+		fmt.Printf(" * Note:    feed is malformed and needed fixes to parse\n")
+	}
+	if feed.NextFetchAt.Valid && !feed.DisabledAt.Valid {
+		fmt.Printf(" * Next:    %v\n", feed.NextFetchAt.Time.Format("2006-01-02 15:04"))
+	}
 	user, err := s.db.GetUserByID(context.Background(), feed.UserID)
 	if err != nil {
 		fmt.Printf("Unable to find user from feed list: %s", feed.UserID)
@@ -92,17 +186,31 @@ func handlerListFeeds(s *state, cmd command) error {
 
 func handlerFollow(s *state, cmd command, user database.User) error {
 	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %v <url>", cmd.Name)
+		return fmt.Errorf("usage: %v <feed id|name|url>", cmd.Name)
 	}
 
-	url := cmd.Args[0]
-	
-	// Grab URL from feeds
-	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	feed, err := findFeed(s, cmd.Args[0])
 	if err != nil {
-		return fmt.Errorf("Unable to find existing feed %s", url)
+		return err
 	}
 
+	return followFeed(s, user, feed)
+}
+
+// followExistingFeed follows a feed someone already added. Credentials
+// given to addfeed aren't applied to it, since they would replace the ones
+// its fetches already use.
+func followExistingFeed(s *state, user database.User, feed database.Feed, header http.Header) error {
+	if len(header) > 0 {
+		return fmt.Errorf("feed %s was already added, store its credentials with: gator credentials %s", feed.Url, feed.ID)
+	}
+	fmt.Println("Feed already exists:")
+	printFeed(feed, s)
+	return followFeed(s, user, feed)
+}
+
+// followFeed subscribes user to feed.
+func followFeed(s *state, user database.User, feed database.Feed) error {
 	follow , err := s.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
 		ID:    uuid.New(),
 		CreatedAt: time.Now().UTC(),
@@ -134,6 +242,10 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 	}
 
 	for _, feed := range feeds {
+		if feed.DisabledAt.Valid {
+			fmt.Println("* ", feed.FeedName, "(disabled)")
+			continue
+		}
 		fmt.Println("* ", feed.FeedName)
 	}
 	
@@ -142,30 +254,97 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 
 func handlerUnfollow(s *state, cmd command, user database.User) error {
 	if len(cmd.Args) != 1 {
-		return fmt.Errorf("usage: %v <url>", cmd.Name)
+		return fmt.Errorf("usage: %v <feed id|name|url>", cmd.Name)
 	}
 
-	url := cmd.Args[0]
-
-	// Grab URL from feeds
-	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	feed, err := findFeed(s, cmd.Args[0])
 	if err != nil {
-		return fmt.Errorf("Unable to find existing feed %s", url)
+		return err
 	}
 
-  unfollowResult := s.db.DeleteUserFeed(context.Background(), database.DeleteUserFeedParams{
+	err = s.db.DeleteUserFeed(context.Background(), database.DeleteUserFeedParams{
 		UserID:    user.ID,
 		FeedID:    feed.ID,
 	})
 	if err != nil {
-		return fmt.Errorf("couldn't unfollow: %w", unfollowResult)
+		return fmt.Errorf("couldn't unfollow: %w", err)
 	}
 
+	fmt.Printf("Unfollowed: %s\n", feed.Name)
+	return nil
+}
+
+func handlerFullText(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 2 || (cmd.Args[1] != "on" && cmd.Args[1] != "off") {
+		return fmt.Errorf("usage: %v <feed id|name|url> <on|off>", cmd.Name)
+	}
+
+	enabled := cmd.Args[1] == "on"
+
+	feed, err := findFeed(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can change its settings", feed.Name)
+	}
+
+	err = s.db.SetFeedFullText(context.Background(), database.SetFeedFullTextParams{
+		FetchFullText: enabled,
+		UpdatedAt:     time.Now().UTC(),
+		ID:            feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't update feed: %w", err)
+	}
+
+	fmt.Printf("Full text extraction for %s: %s\n", feed.Name, cmd.Args[1])
+	return nil
+}
+
+func handlerInterval(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 2 {
+		return fmt.Errorf("usage: %v <feed id|name|url> <duration|auto|adaptive>", cmd.Name)
+	}
+
+	feed, err := findFeed(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can change its settings", feed.Name)
+	}
+
+	params := database.SetFeedIntervalParams{
+		UpdatedAt: time.Now().UTC(),
+		ID:        feed.ID,
+	}
+	switch cmd.Args[1] {
+	case "auto":
+	case "adaptive":
+		params.AdaptiveInterval = true
+	default:
+		interval, err := time.ParseDuration(cmd.Args[1])
+		if err != nil || interval < time.Second {
+			return fmt.Errorf("invalid interval %q, use a duration like 15m, auto or adaptive", cmd.Args[1])
+		}
+		params.FetchInterval = int32(interval / time.Second)
+	}
+
+	err = s.db.SetFeedInterval(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't update feed: %w", err)
+	}
+
+	fmt.Printf("Fetch interval for %s: %s\n", feed.Name, cmd.Args[1])
 	return nil
 }
 
 func handlerBrowse(s *state, cmd command) error {
-	
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %v <limit>", cmd.Name)
+	}
+
 	amount, err := strconv.Atoi(cmd.Args[0])
 	if err != nil {
 		return err
@@ -181,11 +360,63 @@ func handlerBrowse(s *state, cmd command) error {
 	}
 
 	for _, post := range posts {
+		fmt.Printf("* %v\n", post.ID)
 		fmt.Printf("* %v\n", post.Title)
 		fmt.Printf("* %v\n", post.Url)
-		fmt.Printf("* %v\n", post.Description)
+		fmt.Printf("* %v\n", postBody(post))
 		fmt.Printf("* %v\n", post.PublishedAt)
+		enclosures, err := s.db.GetEnclosuresForPost(context.Background(), post.ID)
+		if err != nil {
+			return err
+		}
+		for _, enclosure := range enclosures {
+			printEnclosure(enclosure)
+		}
 	}
 	
 	return nil
 }
+
+func handlerShow(s *state, cmd command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %v <post id|url>", cmd.Name)
+	}
+
+	post, err := getPostByRef(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("Unable to find post %s", cmd.Args[0])
+	}
+
+	fmt.Printf("Title:     %v\n", post.Title)
+	fmt.Printf("URL:       %v\n", post.Url)
+	fmt.Printf("Published: %v\n", post.PublishedAt)
+	fmt.Println()
+	fmt.Println(postBody(post))
+	return nil
+}
+
+// getPostByRef looks a post up by its ID, falling back to its URL, which
+// may be given in any form that normalizes to the stored one.
+func getPostByRef(s *state, ref string) (database.Post, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return s.db.GetPost(context.Background(), id)
+	}
+	normalized, err := urlnorm.Normalize(ref)
+	if err != nil {
+		return s.db.GetPostByURL(context.Background(), ref)
+	}
+	post, err := s.db.GetPostByURL(context.Background(), normalized)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.db.GetPostByURL(context.Background(), urlnorm.WithOtherScheme(normalized))
+	}
+	return post, err
+}
+
+// postBody returns the full article content when the feed provided it and
+// the description otherwise.
+func postBody(post database.Post) string {
+	if post.Content != "" {
+		return post.Content
+	}
+	return post.Description
+}
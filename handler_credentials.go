@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func handlerCredentials(s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %v <feed id|name|url> [basic <user> <password> | bearer <token> | header <name> <value> | clear]", cmd.Name)
+	if len(cmd.Args) < 1 {
+		return usage
+	}
+
+	feed, err := findFeed(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can change its settings", feed.Name)
+	}
+
+	args := cmd.Args[1:]
+	if len(args) == 0 {
+		return printFeedHeaders(s, feed)
+	}
+
+	header := http.Header{}
+	switch {
+	case args[0] == "basic" && len(args) == 3:
+		header.Set("Authorization", basicAuth(args[1], args[2]))
+	case args[0] == "bearer" && len(args) == 2:
+		header.Set("Authorization", "Bearer "+args[1])
+	case args[0] == "header" && len(args) == 3:
+		header.Set(args[1], args[2])
+	case args[0] == "clear" && len(args) == 1:
+		err = s.db.DeleteFeedHeaders(context.Background(), feed.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't clear credentials: %w", err)
+		}
+		fmt.Printf("Cleared credentials and headers for %s\n", feed.Name)
+		return nil
+	default:
+		return usage
+	}
+
+	err = saveFeedHeaders(s, feed.ID, header)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s for %s\n", args[0], feed.Name)
+	return nil
+}
+
+// printFeedHeaders lists the headers stored for feed without revealing their
+// values.
+func printFeedHeaders(s *state, feed database.Feed) error {
+	headers, err := s.db.GetFeedHeaders(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't list headers: %w", err)
+	}
+	if len(headers) == 0 {
+		fmt.Printf("No credentials or headers for %s\n", feed.Name)
+		return nil
+	}
+	for _, header := range headers {
+		fmt.Printf("* %v: %v\n", header.Name, maskValue(header.Value))
+	}
+	return nil
+}
+
+// authSchemes are the Authorization schemes maskValue leaves visible.
+var authSchemes = []string{"Basic", "Bearer", "Digest", "Token"}
+
+// maskValue hides a header value, keeping only a known Authorization scheme
+// such as "Basic" in front of it.
+func maskValue(value string) string {
+	scheme, _, found := strings.Cut(value, " ")
+	if found {
+		for _, known := range authSchemes {
+			if strings.EqualFold(scheme, known) {
+				return known + " ****"
+			}
+		}
+	}
+	return "****"
+}
+
+func basicAuth(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+// parseCredentialFlags strips the leading --basic user:password, --bearer
+// token and --header "Name: value" flags from args and returns the headers
+// they ask for along with the remaining arguments.
+func parseCredentialFlags(args []string) (http.Header, []string, error) {
+	header := http.Header{}
+	for len(args) >= 2 && strings.HasPrefix(args[0], "--") {
+		flag, value := args[0], args[1]
+		switch flag {
+		case "--basic":
+			user, password, found := strings.Cut(value, ":")
+			if !found {
+				return nil, nil, fmt.Errorf("--basic expects user:password")
+			}
+			header.Set("Authorization", basicAuth(user, password))
+		case "--bearer":
+			header.Set("Authorization", "Bearer "+value)
+		case "--header":
+			name, headerValue, found := strings.Cut(value, ":")
+			if !found || strings.TrimSpace(name) == "" {
+				return nil, nil, fmt.Errorf("--header expects \"Name: value\"")
+			}
+			header.Set(strings.TrimSpace(name), strings.TrimSpace(headerValue))
+		default:
+			return nil, nil, fmt.Errorf("unknown flag %s", flag)
+		}
+		args = args[2:]
+	}
+	return header, args, nil
+}
+
+// feedHeaders returns the extra headers stored for a feed.
+func feedHeaders(s *state, feedID uuid.UUID) (http.Header, error) {
+	headers, err := s.db.GetFeedHeaders(context.Background(), feedID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load feed headers: %w", err)
+	}
+	header := http.Header{}
+	for _, h := range headers {
+		header.Set(h.Name, h.Value)
+	}
+	return header, nil
+}
+
+func saveFeedHeaders(s *state, feedID uuid.UUID, header http.Header) error {
+	for name := range header {
+		err := s.db.SetFeedHeader(context.Background(), database.SetFeedHeaderParams{
+			FeedID:    feedID,
+			Name:      name,
+			Value:     header.Get(name),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't save header %s: %w", name, err)
+		}
+	}
+	return nil
+}
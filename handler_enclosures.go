@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func handlerEnclosures(s *state, cmd command) error {
+	args := cmd.Args
+	download := len(args) > 0 && args[0] == "--download"
+	if download {
+		args = args[1:]
+	}
+	if len(args) < 1 || len(args) > 2 || (!download && len(args) != 1) {
+		return fmt.Errorf("usage: %v [--download] <post id|url> [dir]", cmd.Name)
+	}
+
+	post, err := getPostByRef(s, args[0])
+	if err != nil {
+		return fmt.Errorf("Unable to find post %s", args[0])
+	}
+
+	enclosures, err := s.db.GetEnclosuresForPost(context.Background(), post.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't list enclosures: %w", err)
+	}
+	if len(enclosures) == 0 {
+		fmt.Printf("No enclosures for %s\n", post.Title)
+		return nil
+	}
+
+	if !download {
+		for _, enclosure := range enclosures {
+			printEnclosure(enclosure)
+		}
+		return nil
+	}
+
+	dir := "."
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("couldn't create %s: %w", dir, err)
+	}
+
+	used := map[string]bool{}
+	for _, enclosure := range enclosures {
+		name := enclosureFileName(post, enclosure)
+		if used[name] {
+			name = enclosure.ID.String() + "-" + name
+		}
+		used[name] = true
+		dest := filepath.Join(dir, name)
+		if _, err := os.Stat(dest); err == nil {
+			fmt.Printf("Already downloaded: %s\n", dest)
+			continue
+		}
+
+		fmt.Printf("Downloading %s -> %s\n", enclosure.Url, dest)
+		written, err := s.fetcher.downloadEnclosure(context.Background(), enclosure.Url, dest)
+		if err != nil {
+			return fmt.Errorf("couldn't download %s: %w", enclosure.Url, err)
+		}
+		fmt.Printf("Done (%d bytes)\n", written)
+	}
+	return nil
+}
+
+func printEnclosure(enclosure database.Enclosure) {
+	fmt.Printf("  - %v", enclosure.Url)
+	if enclosure.MimeType != "" {
+		fmt.Printf(" [%v]", enclosure.MimeType)
+	}
+	if enclosure.Length > 0 {
+		fmt.Printf(" %d bytes", enclosure.Length)
+	}
+	if enclosure.Duration > 0 {
+		fmt.Printf(" %v", formatDuration(enclosure.Duration))
+	}
+	fmt.Println()
+}
+
+// enclosureFileName names the downloaded file after the post and the last
+// path segment of the enclosure URL, falling back to the enclosure ID, so
+// files gator didn't download are never mistaken for its own.
+func enclosureFileName(post database.Post, enclosure database.Enclosure) string {
+	name := enclosure.ID.String()
+	u, err := url.Parse(enclosure.Url)
+	if err == nil {
+		base := path.Base(u.Path)
+		if base != "." && base != "/" && base != "" {
+			name = base
+		}
+	}
+	return post.ID.String() + "-" + name
+}
+
+// errBadRange is returned when a server answers a Range request with a
+// different part of the file than was asked for.
+var errBadRange = errors.New("server didn't resume the download where it stopped")
+
+// downloadEnclosure fetches rawURL into dest. The data goes to dest.part
+// first, which is resumed with a Range request when the server supports it,
+// and is renamed to dest once complete. It returns the size of dest.
+func (f *feedFetcher) downloadEnclosure(ctx context.Context, rawURL, dest string) (int64, error) {
+	part := dest + ".part"
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	size, err := f.downloadPart(ctx, rawURL, part, offset)
+	if errors.Is(err, errBadRange) && offset > 0 {
+		// Start over rather than stitch together the wrong bytes
+		size, err = f.downloadPart(ctx, rawURL, part, 0)
+	}
+	if err != nil {
+		return size, err
+	}
+	return size, os.Rename(part, dest)
+}
+
+// downloadPart writes rawURL to part, asking for the bytes from offset on
+// when offset isn't 0. It returns the size of part.
+func (f *feedFetcher) downloadPart(ctx context.Context, rawURL, part string, offset int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	// Media files can take much longer than the request timeout to download
+	httpClient := *f.client
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || offset == 0 || start != offset {
+			return 0, errBadRange
+		}
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		flags |= os.O_TRUNC
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Only done when the part already holds the whole file
+		_, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || offset == 0 || total != offset {
+			return 0, errBadRange
+		}
+		return offset, nil
+	default:
+		return 0, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	file, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, resp.Body)
+	if err != nil {
+		return offset + written, err
+	}
+	return offset + written, file.Close()
+}
+
+// parseContentRange reads a Content-Range header such as "bytes 100-199/200"
+// or "bytes */200". start is -1 when no range is given, total is -1 when the
+// size is unknown.
+func parseContentRange(value string) (start, total int64, err error) {
+	rangeSpec, ok := strings.CutPrefix(strings.TrimSpace(value), "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	span, size, ok := strings.Cut(rangeSpec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+
+	start, total = -1, -1
+	if span != "*" {
+		first, _, ok := strings.Cut(span, "-")
+		if !ok {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+		}
+		start, err = strconv.ParseInt(first, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+		}
+	}
+	if size != "*" {
+		total, err = strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+		}
+	}
+	return start, total, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func TestDownloadEnclosureResumes(t *testing.T) {
+	s := newTestState(t)
+	media := bytes.Repeat([]byte("0123456789"), 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "episode.mp3", time.Time{}, bytes.NewReader(media))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "episode.mp3")
+	// An unrelated file with the final name's prefix must be left alone
+	if err := os.WriteFile(filepath.Join(dir, "episode"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest+".part", media[:300], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := s.fetcher.downloadEnclosure(context.Background(), server.URL+"/episode.mp3", dest)
+	if err != nil || written != int64(len(media)) {
+		t.Fatalf("downloadEnclosure = %d, %v", written, err)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, media) {
+		t.Errorf("downloaded %d bytes that don't match the file", len(got))
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Errorf("the partial download wasn't renamed: %v", err)
+	}
+	mine, _ := os.ReadFile(filepath.Join(dir, "episode"))
+	if string(mine) != "mine" {
+		t.Errorf("unrelated file changed to %q", mine)
+	}
+}
+
+func TestDownloadEnclosureChecksContentRange(t *testing.T) {
+	s := newTestState(t)
+	media := []byte("the whole episode")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			// Claims a partial response but starts from the beginning
+			w.Header().Set("Content-Range", "bytes 0-16/17")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(media)
+			return
+		}
+		w.Write(media)
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(dest+".part", []byte("the whole"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.fetcher.downloadEnclosure(context.Background(), server.URL, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, media) {
+		t.Errorf("downloaded %q, want %q", got, media)
+	}
+}
+
+func TestDownloadEnclosureRangeNotSatisfiable(t *testing.T) {
+	s := newTestState(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			w.Header().Set("Content-Range", "bytes */5")
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.Write([]byte("fresh"))
+	}))
+	defer server.Close()
+
+	// A part larger than the file isn't a finished download
+	dest := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(dest+".part", []byte("stale bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	written, err := s.fetcher.downloadEnclosure(context.Background(), server.URL, dest)
+	if err != nil || written != 5 {
+		t.Fatalf("downloadEnclosure = %d, %v", written, err)
+	}
+	got, _ := os.ReadFile(dest)
+	if string(got) != "fresh" {
+		t.Errorf("downloaded %q", got)
+	}
+}
+
+func TestEnclosureFileNamesDontCollide(t *testing.T) {
+	first := database.Post{ID: uuid.New()}
+	second := database.Post{ID: uuid.New()}
+	enclosure := database.Enclosure{ID: uuid.New(), Url: "https://example.com/a/episode.mp3"}
+	other := database.Enclosure{ID: uuid.New(), Url: "https://example.org/b/episode.mp3"}
+
+	a := enclosureFileName(first, enclosure)
+	b := enclosureFileName(second, other)
+	if a == b {
+		t.Errorf("both enclosures are saved as %s", a)
+	}
+	if a != first.ID.String()+"-episode.mp3" {
+		t.Errorf("enclosureFileName = %s", a)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value        string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"bytes */200", -1, 200, true},
+		{"bytes=100-199/200", 0, 0, false},
+		{"bytes 100-199", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, err := parseContentRange(tt.value)
+		if (err == nil) != tt.ok || start != tt.start || total != tt.total {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v", tt.value, start, total, err)
+		}
+	}
+}
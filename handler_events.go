@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func handlerEvents(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage: %v [limit]", cmd.Name)
+	}
+
+	limit := 20
+	if len(cmd.Args) == 1 {
+		n, err := strconv.Atoi(cmd.Args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid limit %q", cmd.Args[0])
+		}
+		limit = n
+	}
+
+	events, err := s.db.GetFeedEventsForUser(context.Background(), database.GetFeedEventsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't list feed events: %w", err)
+	}
+
+	for _, event := range events {
+		fmt.Printf("* %v [%v] %v: %v\n", event.CreatedAt.Format("2006-01-02 15:04"), event.Kind, event.FeedName, event.Message)
+	}
+	return nil
+}
+
+// handlerEnable fetches a feed again after it was disabled, for when a feed
+// that answered 410 Gone comes back.
+func handlerEnable(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %v <feed id|name|url>", cmd.Name)
+	}
+
+	feed, err := findFeed(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can change its settings", feed.Name)
+	}
+	if !feed.DisabledAt.Valid {
+		fmt.Printf("%s isn't disabled\n", feed.Name)
+		return nil
+	}
+
+	err = s.db.SetFeedDisabled(context.Background(), database.SetFeedDisabledParams{
+		DisabledAt: sql.NullTime{},
+		UpdatedAt:  time.Now().UTC(),
+		ID:         feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't enable feed: %w", err)
+	}
+	// Fetch it on the next agg cycle
+	err = s.db.ScheduleFeedFetch(context.Background(), database.ScheduleFeedFetchParams{ID: feed.ID})
+	if err != nil {
+		return fmt.Errorf("couldn't schedule feed: %w", err)
+	}
+	recordFeedEvent(s, feed.ID, feedEventEnabled, fmt.Sprintf("%s was enabled again by %s", feed.Url, user.Name))
+
+	fmt.Printf("%s is fetched again\n", feed.Name)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func handlerFetchLog(s *state, cmd command) error {
+	usage := fmt.Errorf("usage: %v [--feed <feed id|name|url>] [--since <duration>]", cmd.Name)
+
+	params := database.GetFetchLogParams{
+		Since: time.Now().UTC().Add(-24 * time.Hour),
+	}
+	args := cmd.Args
+	for len(args) > 0 {
+		if len(args) < 2 {
+			return usage
+		}
+		switch args[0] {
+		case "--feed":
+			feed, err := findFeed(s, args[1])
+			if err != nil {
+				return err
+			}
+			params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		case "--since":
+			since, err := time.ParseDuration(args[1])
+			if err != nil || since <= 0 {
+				return fmt.Errorf("invalid duration %q, use something like 1h or 30m", args[1])
+			}
+			params.Since = time.Now().UTC().Add(-since)
+		default:
+			return usage
+		}
+		args = args[2:]
+	}
+
+	entries, err := s.db.GetFetchLog(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't read fetch log: %w", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No fetches in that period")
+		return nil
+	}
+
+	var total struct {
+		failed, seen, added, updated int
+		bytes                        int64
+		took                         time.Duration
+	}
+	for _, entry := range entries {
+		printFetchLogEntry(entry)
+		if entry.Error != "" {
+			total.failed++
+		}
+		total.seen += int(entry.ItemsSeen)
+		total.added += int(entry.ItemsNew)
+		total.updated += int(entry.ItemsUpdated)
+		total.bytes += entry.Bytes
+		total.took += entry.FinishedAt.Sub(entry.StartedAt)
+	}
+
+	fmt.Println()
+	fmt.Printf("Fetches: %d (%d failed), average %v\n", len(entries), total.failed, (total.took / time.Duration(len(entries))).Round(time.Millisecond))
+	fmt.Printf("Items:   %d seen, %d new, %d updated\n", total.seen, total.added, total.updated)
+	fmt.Printf("Bytes:   %d\n", total.bytes)
+	return nil
+}
+
+func printFetchLogEntry(entry database.GetFetchLogRow) {
+	status := "-"
+	if entry.StatusCode != 0 {
+		status = fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(int(entry.StatusCode)))
+	}
+	fmt.Printf("* %v %v (%v) %v, %v\n",
+		entry.StartedAt.Format("2006-01-02 15:04:05"),
+		entry.FeedName,
+		entry.FeedUrl,
+		status,
+		entry.FinishedAt.Sub(entry.StartedAt).Round(time.Millisecond),
+	)
+	if entry.Error != "" {
+		fmt.Printf("    error: %v\n", entry.Error)
+		return
+	}
+	fmt.Printf("    %d bytes, %d items, %d new, %d updated\n", entry.Bytes, entry.ItemsSeen, entry.ItemsNew, entry.ItemsUpdated)
+}
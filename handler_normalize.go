@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/urlnorm"
+)
+
+// handlerNormalize rewrites stored feed and post URLs into their normalized
+// form and merges rows that turn out to be duplicates. The oldest row of each
+// group is kept; followers and posts of merged feeds move over to it.
+func handlerNormalize(s *state, cmd command) error {
+	if len(cmd.Args) != 0 {
+		return fmt.Errorf("usage: %v", cmd.Name)
+	}
+
+	mergedFeeds, err := normalizeFeeds(s)
+	if err != nil {
+		return err
+	}
+	mergedPosts, err := normalizePosts(s)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged %d duplicate feeds and %d duplicate posts\n", mergedFeeds, mergedPosts)
+	return nil
+}
+
+func normalizeFeeds(s *state) (int, error) {
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("couldn't list feeds: %w", err)
+	}
+
+	merged := 0
+	kept := map[string]database.Feed{}
+	for _, feed := range oldestFirst(feeds, func(f database.Feed) time.Time { return f.CreatedAt }) {
+		key, err := urlnorm.Key(feed.Url)
+		if err != nil {
+			fmt.Printf("Skipping feed %s with invalid URL %s: %v\n", feed.Name, feed.Url, err)
+			continue
+		}
+
+		keep, ok := kept[key]
+		if !ok {
+			kept[key] = feed
+			continue
+		}
+
+		fmt.Printf("Merging feed %s into %s\n", feed.Url, keep.Url)
+		err = s.db.MoveFeedFollows(context.Background(), database.MoveFeedFollowsParams{
+			ToFeedID:   keep.ID,
+			FromFeedID: feed.ID,
+		})
+		if err != nil {
+			return merged, fmt.Errorf("couldn't move followers of %s: %w", feed.Url, err)
+		}
+		err = s.db.MovePosts(context.Background(), database.MovePostsParams{
+			ToFeedID:   keep.ID,
+			FromFeedID: feed.ID,
+		})
+		if err != nil {
+			return merged, fmt.Errorf("couldn't move posts of %s: %w", feed.Url, err)
+		}
+		err = s.db.DeleteFeed(context.Background(), feed.ID)
+		if err != nil {
+			return merged, fmt.Errorf("couldn't delete feed %s: %w", feed.Url, err)
+		}
+		merged++
+	}
+
+	for _, feed := range kept {
+		normalized, _ := urlnorm.Normalize(feed.Url)
+		if normalized == feed.Url {
+			continue
+		}
+		err := s.db.UpdateFeedURL(context.Background(), database.UpdateFeedURLParams{
+			Url:       normalized,
+			UpdatedAt: time.Now().UTC(),
+			ID:        feed.ID,
+		})
+		if err != nil {
+			return merged, fmt.Errorf("couldn't update feed %s: %w", feed.Url, err)
+		}
+	}
+	return merged, nil
+}
+
+func normalizePosts(s *state) (int, error) {
+	posts, err := s.db.GetPosts(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("couldn't list posts: %w", err)
+	}
+
+	merged := 0
+	kept := map[string]database.Post{}
+	for _, post := range oldestFirst(posts, func(p database.Post) time.Time { return p.CreatedAt }) {
+		key, err := urlnorm.Key(post.Url)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := kept[key]; !ok {
+			kept[key] = post
+			continue
+		}
+
+		err = s.db.DeletePost(context.Background(), post.ID)
+		if err != nil {
+			return merged, fmt.Errorf("couldn't delete post %s: %w", post.Url, err)
+		}
+		merged++
+	}
+
+	for _, post := range kept {
+		normalized, _ := urlnorm.Normalize(post.Url)
+		if normalized == post.Url {
+			continue
+		}
+		err := s.db.UpdatePostURL(context.Background(), database.UpdatePostURLParams{
+			Url:       normalized,
+			UpdatedAt: time.Now().UTC(),
+			ID:        post.ID,
+		})
+		if err != nil {
+			return merged, fmt.Errorf("couldn't update post %s: %w", post.Url, err)
+		}
+	}
+	return merged, nil
+}
+
+func oldestFirst[T any](items []T, createdAt func(T) time.Time) []T {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		return createdAt(a).Compare(createdAt(b))
+	})
+	return sorted
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func TestNormalizeMergesDuplicateFeeds(t *testing.T) {
+	s := newTestState(t)
+	ctx := context.Background()
+	mustRun(t, s, "register", "bob")
+	mustRun(t, s, "register", "alice")
+	bob, _ := s.db.GetUser(ctx, "bob")
+
+	addFeed := func(url string, created time.Time) database.Feed {
+		t.Helper()
+		feed, err := s.db.AddFeed(ctx, database.AddFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: created,
+			UpdatedAt: created,
+			Name:      url,
+			Url:       url,
+			UserID:    bob.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return feed
+	}
+	addPost := func(feed database.Feed, url string) database.Post {
+		t.Helper()
+		post, err := s.db.CreatePost(ctx, database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+			Title:       url,
+			Url:         url,
+			PublishedAt: time.Now().UTC(),
+			FeedID:      feed.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return post
+	}
+
+	// Added before URLs were normalized, the same feed twice
+	kept := addFeed("https://example.com/feed/", time.Now().Add(-time.Hour).UTC())
+	duplicate := addFeed("HTTPS://Example.com:443/feed/?utm_source=newsletter", time.Now().UTC())
+	other := addFeed("https://example.com/feed", time.Now().UTC())
+	_, err := s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    bob.ID,
+		FeedID:    duplicate.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := addPost(duplicate, "https://example.com/posts/1")
+	addPost(kept, "https://example.com/posts/2")
+	addPost(kept, "https://EXAMPLE.com/posts/2#comments")
+
+	out := mustRun(t, s, "normalize")
+	if !strings.Contains(out, "Merged 1 duplicate feeds and 1 duplicate posts") {
+		t.Errorf("normalize printed:\n%s", out)
+	}
+
+	feeds, _ := s.db.GetFeeds(ctx)
+	if len(feeds) != 2 {
+		t.Fatalf("feeds after normalize = %+v", feeds)
+	}
+	// A trailing slash makes a different URL
+	if _, err := s.db.GetFeed(ctx, other.ID); err != nil {
+		t.Errorf("feed without the trailing slash was merged: %v", err)
+	}
+	if _, err := s.db.GetFeed(ctx, duplicate.ID); err == nil {
+		t.Error("duplicate feed wasn't deleted")
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(ctx, bob.ID)
+	if err != nil || len(follows) != 1 || follows[0].FeedID != kept.ID {
+		t.Errorf("bob's follows = %+v, %v", follows, err)
+	}
+	post, err := s.db.GetPost(ctx, moved.ID)
+	if err != nil || post.FeedID != kept.ID {
+		t.Errorf("post of the duplicate feed = %+v, %v", post, err)
+	}
+	posts, _ := s.db.GetPosts(ctx)
+	if len(posts) != 2 {
+		t.Errorf("%d posts after normalize, want 2", len(posts))
+	}
+}
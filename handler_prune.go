@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func handlerPrune(s *state, cmd command) error {
+	dryRun := len(cmd.Args) == 1 && cmd.Args[0] == "--dry-run"
+	if len(cmd.Args) > 1 || (len(cmd.Args) == 1 && !dryRun) {
+		return fmt.Errorf("usage: %v [--dry-run]", cmd.Name)
+	}
+
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't list feeds: %w", err)
+	}
+
+	total := 0
+	for _, feed := range feeds {
+		posts, err := pruneFeed(s, feed, dryRun)
+		total += len(posts)
+		if err != nil {
+			return fmt.Errorf("couldn't prune %s: %w", feed.Name, err)
+		}
+		if len(posts) == 0 {
+			continue
+		}
+		fmt.Printf("* %v (%v): %d posts\n", feed.Name, feedRetention(s.cfg, feed), len(posts))
+		if dryRun {
+			for _, post := range posts {
+				fmt.Printf("    %v %v\n", post.PublishedAt.Format("2006-01-02"), post.Title)
+			}
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("Would remove %d posts\n", total)
+		return nil
+	}
+	fmt.Printf("Removed %d posts\n", total)
+	return nil
+}
+
+func handlerRetention(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 && len(cmd.Args) != 3 {
+		return fmt.Errorf("usage: %v <feed id|name|url> [<days|default> <items|default>]", cmd.Name)
+	}
+
+	feed, err := findFeed(s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if len(cmd.Args) == 1 {
+		fmt.Printf("Retention for %s: %v\n", feed.Name, feedRetention(s.cfg, feed))
+		return nil
+	}
+	if feed.UserID != user.ID {
+		return fmt.Errorf("only the user who added %s can change its settings", feed.Name)
+	}
+
+	days, err := parseRetentionLimit(cmd.Args[1])
+	if err != nil {
+		return err
+	}
+	items, err := parseRetentionLimit(cmd.Args[2])
+	if err != nil {
+		return err
+	}
+
+	err = s.db.SetFeedRetention(context.Background(), database.SetFeedRetentionParams{
+		RetentionDays:  days,
+		RetentionItems: items,
+		UpdatedAt:      time.Now().UTC(),
+		ID:             feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't update feed: %w", err)
+	}
+
+	feed.RetentionDays = days
+	feed.RetentionItems = items
+	fmt.Printf("Retention for %s: %v\n", feed.Name, feedRetention(s.cfg, feed))
+	return nil
+}
+
+// parseRetentionLimit parses a number of days or items, where 0 means no
+// limit and default means the config's limit.
+func parseRetentionLimit(arg string) (sql.NullInt32, error) {
+	if arg == "default" {
+		return sql.NullInt32{}, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return sql.NullInt32{}, fmt.Errorf("invalid limit %q, use a number, 0 for no limit or default", arg)
+	}
+	return sql.NullInt32{Int32: int32(n), Valid: true}, nil
+}
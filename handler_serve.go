@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// handlerServe runs the aggregator together with an HTTP server that
+// receives WebSub callbacks, so feeds with a hub are pushed instead of
+// polled.
+func handlerServe(s *state, cmd command) error {
+	if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage: %v [time_between_reqs (1s, 1m, 1h)]", cmd.Name)
+	}
+	timeBetweenReqs := time.Minute
+	if len(cmd.Args) == 1 {
+		d, err := time.ParseDuration(cmd.Args[0])
+		if err != nil {
+			return fmt.Errorf("Error parsing time duration: %v", err)
+		}
+		timeBetweenReqs = d
+	}
+
+	if s.cfg.PublicURL == "" {
+		slog.Warn("public_url isn't set in the config, WebSub subscriptions are disabled")
+	} else {
+		s.websub = newWebSubscriber(s.cfg.PublicURL, s.fetcher.client)
+	}
+
+	var current atomic.Pointer[state]
+	current.Store(s)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /websub/{feedID}", handleWebSubVerify(&current))
+	mux.HandleFunc("POST /websub/{feedID}", handleWebSubPush(&current))
+	mux.Handle("GET /metrics", s.metrics.handler())
+	server := &http.Server{
+		Addr:              s.cfg.ListenAddr(),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serverErr := make(chan error, 1)
+	go func() {
+		err := server.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+			cancel()
+		}
+	}()
+	slog.Info("listening", "addr", server.Addr)
+
+	runDaemon(ctx, &current, timeBetweenReqs, func(s *state) {
+		err := scrapeFeeds(s)
+		if err != nil {
+			slog.Error("couldn't list due feeds", "error", err)
+		}
+		renewWebSubSubscriptions(s)
+	})
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server stopped: %w", err)
+	default:
+	}
+	// Let pushes being ingested finish
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelShutdown()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func handlerStar(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage: %v [post id|url]", cmd.Name)
+	}
+
+	if len(cmd.Args) == 0 {
+		posts, err := s.db.GetStarredPostsForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't list starred posts: %w", err)
+		}
+		for _, post := range posts {
+			fmt.Printf("* %v\n", post.Title)
+			fmt.Printf("  %v\n", post.Url)
+			fmt.Printf("  %v\n", post.ID)
+		}
+		return nil
+	}
+
+	post, err := getPostByRef(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("Unable to find post %s", cmd.Args[0])
+	}
+	err = s.db.StarPost(context.Background(), database.StarPostParams{
+		UserID:    user.ID,
+		PostID:    post.ID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't star post: %w", err)
+	}
+
+	fmt.Printf("Starred: %s\n", post.Title)
+	return nil
+}
+
+func handlerUnstar(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %v <post id|url>", cmd.Name)
+	}
+
+	post, err := getPostByRef(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("Unable to find post %s", cmd.Args[0])
+	}
+	err = s.db.UnstarPost(context.Background(), database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unstar post: %w", err)
+	}
+
+	fmt.Printf("Unstarred: %s\n", post.Title)
+	return nil
+}
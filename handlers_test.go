@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/config"
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/feedtest"
+	"github.com/mortalglitch/gator/internal/memstore"
+)
+
+// newTestState returns a state backed by an in-memory store. The config
+// file login and register write goes to a temporary home directory.
+func newTestState(t *testing.T) *state {
+	return newTestStateWithConfig(t, &config.Config{HostRequestDelay: "0s"})
+}
+
+func newTestStateWithConfig(t *testing.T, cfg *config.Config) *state {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	fetcher, err := newFeedFetcher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &state{
+		db:      memstore.New(),
+		cfg:     cfg,
+		fetcher: fetcher,
+		limiter: newHostLimiter(cfg.MaxHostConnections(), cfg.HostDelay()),
+	}
+}
+
+// run runs a command the way main does, returning what it printed.
+func run(t *testing.T, s *state, name string, args ...string) (string, error) {
+	t.Helper()
+	cmds := newCommands()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var out strings.Builder
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		io.Copy(&out, r)
+	}()
+
+	err = cmds.run(s, command{Name: name, Args: args})
+	w.Close()
+	wg.Wait()
+	return out.String(), err
+}
+
+func mustRun(t *testing.T, s *state, name string, args ...string) string {
+	t.Helper()
+	out, err := run(t, s, name, args...)
+	if err != nil {
+		t.Fatalf("%s %s: %v", name, strings.Join(args, " "), err)
+	}
+	return out
+}
+
+// testFeed serves an RSS feed titled "Test Feed" with a post for each of
+// titles.
+func testFeed(server *feedtest.Server, titles ...string) feedtest.Response {
+	var items []feedtest.Item
+	for i, title := range titles {
+		items = append(items, feedtest.Item{
+			Title:       title,
+			Link:        fmt.Sprintf("%s/posts/%d", server.URL, i),
+			Description: "About " + title,
+			Published:   time.Date(2024, time.January, i+1, 10, 0, 0, 0, time.UTC),
+		})
+	}
+	return feedtest.OK(feedtest.RSS("Test Feed", items...))
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestState(t)
+
+	mustRun(t, s, "register", "alice")
+	if s.cfg.CurrentUserName != "alice" {
+		t.Errorf("current user = %q after register", s.cfg.CurrentUserName)
+	}
+	_, err := run(t, s, "register", "alice")
+	if err == nil {
+		t.Error("registered alice twice")
+	}
+	mustRun(t, s, "register", "bob")
+
+	_, err = run(t, s, "login", "carol")
+	if err == nil {
+		t.Error("logged in as a user that doesn't exist")
+	}
+	mustRun(t, s, "login", "alice")
+	if s.cfg.CurrentUserName != "alice" {
+		t.Errorf("current user = %q after login", s.cfg.CurrentUserName)
+	}
+	saved, err := config.Read()
+	if err != nil || saved.CurrentUserName != "alice" {
+		t.Errorf("saved config = %+v, %v", saved, err)
+	}
+
+	out := mustRun(t, s, "users")
+	if !strings.Contains(out, "* alice (current)") || !strings.Contains(out, "* bob\n") {
+		t.Errorf("users printed:\n%s", out)
+	}
+
+	_, err = run(t, s, "register")
+	if err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("register without a name: %v", err)
+	}
+}
+
+func TestAddFeedFollowUnfollow(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	feedURL := server.Script("/feed.xml", testFeed(server, "First", "Second"))
+
+	_, err := run(t, s, "addfeed", feedURL)
+	if err == nil {
+		t.Error("addfeed worked without a logged in user")
+	}
+
+	mustRun(t, s, "register", "alice")
+	mustRun(t, s, "addfeed", feedURL)
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil || len(feeds) != 1 {
+		t.Fatalf("feeds = %+v, %v", feeds, err)
+	}
+	if feeds[0].Name != "Test Feed" {
+		t.Errorf("feed name = %q, want the feed's title", feeds[0].Name)
+	}
+	posts, err := s.db.GetPosts(context.Background())
+	if err != nil || len(posts) != 2 {
+		t.Errorf("addfeed imported %d posts, %v", len(posts), err)
+	}
+	out := mustRun(t, s, "following")
+	if !strings.Contains(out, "Test Feed") {
+		t.Errorf("alice is following:\n%s", out)
+	}
+
+	// Adding the same feed again just follows it
+	mustRun(t, s, "register", "bob")
+	_, err = run(t, s, "addfeed", "--header", "X-Api-Key: secret", feedURL)
+	if err == nil || !strings.Contains(err.Error(), "gator credentials") {
+		t.Errorf("addfeed with credentials for an added feed: %v", err)
+	}
+	mustRun(t, s, "addfeed", feedURL)
+	feeds, _ = s.db.GetFeeds(context.Background())
+	if len(feeds) != 1 {
+		t.Errorf("%d feeds after adding the same one twice", len(feeds))
+	}
+	mustRun(t, s, "unfollow", "test feed")
+	out = mustRun(t, s, "following")
+	if strings.Contains(out, "Test Feed") {
+		t.Errorf("bob is still following:\n%s", out)
+	}
+
+	mustRun(t, s, "follow", feeds[0].ID.String())
+	out = mustRun(t, s, "following")
+	if !strings.Contains(out, "Test Feed") {
+		t.Errorf("bob isn't following after follow:\n%s", out)
+	}
+	_, err = run(t, s, "follow", feedURL)
+	if err == nil {
+		t.Error("followed the same feed twice")
+	}
+	_, err = run(t, s, "follow", "No Such Feed")
+	if err == nil {
+		t.Error("followed a feed that doesn't exist")
+	}
+
+	out = mustRun(t, s, "feeds")
+	if !strings.Contains(out, feedURL) || !strings.Contains(out, "alice") {
+		t.Errorf("feeds printed:\n%s", out)
+	}
+}
+
+func TestAddFeedRejectsBrokenFeeds(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	feedURL := server.Script("/feed.xml", feedtest.Status(http.StatusInternalServerError))
+
+	mustRun(t, s, "register", "alice")
+	_, err := run(t, s, "addfeed", "Broken", feedURL)
+	if err == nil {
+		t.Fatal("added a feed that can't be fetched")
+	}
+	feeds, _ := s.db.GetFeeds(context.Background())
+	if len(feeds) != 0 {
+		t.Errorf("broken feed was stored: %+v", feeds)
+	}
+}
+
+func TestAggAndBrowse(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	feedURL := server.Script("/feed.xml", testFeed(server, "First"))
+
+	mustRun(t, s, "register", "alice")
+	mustRun(t, s, "addfeed", feedURL)
+
+	server.Script("/feed.xml", testFeed(server, "First", "Second", "Third"))
+	// addfeed schedules the next fetch, make the feed due now
+	feeds, _ := s.db.GetFeeds(context.Background())
+	err := s.db.ScheduleFeedFetch(context.Background(), database.ScheduleFeedFetchParams{ID: feeds[0].ID, NextFetchAt: sql.NullTime{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, s, "agg", "--once")
+
+	posts, _ := s.db.GetPosts(context.Background())
+	if len(posts) != 3 {
+		t.Fatalf("%d posts after agg, want 3", len(posts))
+	}
+	feed, _ := s.db.GetFeed(context.Background(), feeds[0].ID)
+	if !feed.LastFetchedAt.Valid || !feed.NextFetchAt.Valid {
+		t.Errorf("agg didn't record the fetch: %+v", feed)
+	}
+	log, _ := s.db.GetFetchLog(context.Background(), database.GetFetchLogParams{})
+	if len(log) != 1 || log[0].ItemsNew != 2 {
+		t.Errorf("fetch log = %+v", log)
+	}
+
+	// Nothing is due anymore
+	mustRun(t, s, "agg", "--once")
+	log, _ = s.db.GetFetchLog(context.Background(), database.GetFetchLogParams{})
+	if len(log) != 1 {
+		t.Errorf("agg fetched a feed that wasn't due, %d fetches logged", len(log))
+	}
+
+	out := mustRun(t, s, "browse", "2")
+	if !strings.Contains(out, "First") || !strings.Contains(out, "Second") || strings.Contains(out, "Third") {
+		t.Errorf("browse 2 printed:\n%s", out)
+	}
+	if !strings.Contains(out, "About Second") {
+		t.Errorf("browse didn't print the post body:\n%s", out)
+	}
+
+	// Posts can be referred to by URLs that only normalize to the stored one
+	messy := strings.Replace(server.URL, "http://", "HTTP://", 1) + "/posts/1?utm_source=newsletter#comments"
+	out = mustRun(t, s, "show", messy)
+	if !strings.Contains(out, "Second") {
+		t.Errorf("show %s printed:\n%s", messy, out)
+	}
+	mustRun(t, s, "star", messy)
+
+	_, err = run(t, s, "browse")
+	if err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("browse without a limit: %v", err)
+	}
+	_, err = run(t, s, "agg")
+	if err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("agg without an interval: %v", err)
+	}
+}
+
+func TestAddFeedSkipsUnsupportedFeedLinks(t *testing.T) {
+	s := newTestState(t)
+	server := feedtest.NewServer(t)
+	html := func(links string) feedtest.Response {
+		return feedtest.Response{
+			Header: http.Header{"Content-Type": {"text/html"}},
+			Body:   []byte(`<html><head>` + links + `</head><body>Blog</body></html>`),
+		}
+	}
+	server.Script("/posts.rss", feedtest.OK(feedtest.RSS("Blog")))
+	server.Script("/feed.json", feedtest.Response{Body: []byte(`{"version": "https://jsonfeed.org/version/1.1"}`)})
+	both := server.Script("/both", html(`<link rel="alternate" type="application/feed+json" href="/feed.json">
+<link rel="alternate" type="application/rss+xml" href="/posts.rss">`))
+	jsonOnly := server.Script("/json-only", html(`<link rel="alternate" type="application/feed+json" href="/feed.json">`))
+
+	mustRun(t, s, "register", "alice")
+	_, err := run(t, s, "addfeed", jsonOnly)
+	if err == nil || !strings.Contains(err.Error(), "can't read") || !strings.Contains(err.Error(), "/feed.json") {
+		t.Errorf("addfeed of a page with only a JSON Feed: %v", err)
+	}
+
+	// The RSS feed is picked without asking
+	mustRun(t, s, "addfeed", both)
+	feeds, _ := s.db.GetFeeds(context.Background())
+	if len(feeds) != 1 || !strings.HasSuffix(feeds[0].Url, "/posts.rss") {
+		t.Errorf("feeds = %+v", feeds)
+	}
+}
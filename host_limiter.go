@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRetryAfter is how long a host is left alone after a 429 or 503
+// without a usable Retry-After header.
+const defaultRetryAfter = 5 * time.Minute
+
+// hostLimiter keeps concurrent fetching polite: each host gets a limited
+// number of simultaneous requests, spaced out by a minimum delay, and hosts
+// that ask us to back off are skipped until they're ready.
+type hostLimiter struct {
+	maxConns int
+	delay    time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	slots         chan struct{}
+	nextStart     time.Time
+	deferredUntil time.Time
+}
+
+// hostDeferredError is returned by acquire while a host is backed off.
+type hostDeferredError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *hostDeferredError) Error() string {
+	return fmt.Sprintf("host %s asked to retry after %s", e.Host, e.Until.Format(time.RFC3339))
+}
+
+func newHostLimiter(maxConns int, delay time.Duration) *hostLimiter {
+	return &hostLimiter{
+		maxConns: maxConns,
+		delay:    delay,
+		hosts:    map[string]*hostState{},
+	}
+}
+
+func (l *hostLimiter) host(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &hostState{slots: make(chan struct{}, l.maxConns)}
+		l.hosts[host] = h
+	}
+	return h
+}
+
+// acquire blocks until a request to host may start and returns a function
+// that must be called once the request is done.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	h := l.host(host)
+	if err := l.checkDeferred(host, h); err != nil {
+		return nil, err
+	}
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-h.slots }
+
+	if err := sleepUntil(ctx, l.reserveStart(h)); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// pace blocks until another request to host may start, keeping to the delay
+// between requests without taking a connection slot. It is for requests
+// made while holding a slot for the host already, such as fetching the
+// articles of a feed that is being saved.
+func (l *hostLimiter) pace(ctx context.Context, host string) error {
+	h := l.host(host)
+	if err := l.checkDeferred(host, h); err != nil {
+		return err
+	}
+	return sleepUntil(ctx, l.reserveStart(h))
+}
+
+func (l *hostLimiter) checkDeferred(host string, h *hostState) error {
+	l.mu.Lock()
+	until := h.deferredUntil
+	l.mu.Unlock()
+	if time.Now().Before(until) {
+		return &hostDeferredError{Host: host, Until: until}
+	}
+	return nil
+}
+
+// reserveStart returns when the next request to h may start and pushes the
+// one after back by the delay.
+func (l *hostLimiter) reserveStart(h *hostState) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	start := time.Now()
+	if h.nextStart.After(start) {
+		start = h.nextStart
+	}
+	h.nextStart = start.Add(l.delay)
+	return start
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withLimits returns a limiter with new settings that still leaves alone
+// the hosts l was told to defer.
+func (l *hostLimiter) withLimits(maxConns int, delay time.Duration) *hostLimiter {
+	next := newHostLimiter(maxConns, delay)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for name, h := range l.hosts {
+		if !h.deferredUntil.IsZero() {
+			next.hosts[name] = &hostState{slots: make(chan struct{}, maxConns), deferredUntil: h.deferredUntil}
+		}
+	}
+	return next
+}
+
+// deferHost stops requests to host until the given time.
+func (l *hostLimiter) deferHost(host string, until time.Time) {
+	h := l.host(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(h.deferredUntil) {
+		h.deferredUntil = until
+	}
+}
+
+// retryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(header http.Header, now time.Time) time.Time {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date
+	}
+	return now.Add(defaultRetryAfter)
+}
+
+func feedHost(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return feedURL
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterCapsConnections(t *testing.T) {
+	l := newHostLimiter(2, 0)
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.acquire(context.Background(), "example.com")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer release()
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p != 2 {
+		t.Errorf("%d requests ran at once, want 2", p)
+	}
+
+	// A full host makes further requests wait, other hosts have slots of
+	// their own
+	release, err := l.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	release2, err := l.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release2()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "example.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("third request to a full host: %v", err)
+	}
+	other, err := l.acquire(context.Background(), "example.org")
+	if err != nil {
+		t.Errorf("request to another host: %v", err)
+	} else {
+		other()
+	}
+}
+
+func TestHostLimiterSpacesRequests(t *testing.T) {
+	const delay = 30 * time.Millisecond
+	l := newHostLimiter(4, delay)
+	var starts []time.Time
+	for range 3 {
+		release, err := l.acquire(context.Background(), "example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		starts = append(starts, time.Now())
+		release()
+	}
+	if err := l.pace(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	starts = append(starts, time.Now())
+
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < delay-5*time.Millisecond {
+			t.Errorf("requests %d and %d started %v apart, want at least %v", i-1, i, gap, delay)
+		}
+	}
+
+	// The delay is per host
+	start := time.Now()
+	release, err := l.acquire(context.Background(), "example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if waited := time.Since(start); waited > delay/2 {
+		t.Errorf("first request to another host waited %v", waited)
+	}
+}
+
+func TestHostLimiterDefersHosts(t *testing.T) {
+	l := newHostLimiter(2, 0)
+	until := time.Now().Add(time.Minute)
+	l.deferHost("example.com", until)
+
+	_, err := l.acquire(context.Background(), "example.com")
+	var deferred *hostDeferredError
+	if !errors.As(err, &deferred) || !deferred.Until.Equal(until) {
+		t.Errorf("acquire on a deferred host: %v", err)
+	}
+	if err := l.pace(context.Background(), "example.com"); !errors.As(err, &deferred) {
+		t.Errorf("pace on a deferred host: %v", err)
+	}
+
+	// An earlier deadline doesn't shorten the wait
+	l.deferHost("example.com", time.Now().Add(time.Second))
+	_, err = l.acquire(context.Background(), "example.com")
+	if !errors.As(err, &deferred) || !deferred.Until.Equal(until) {
+		t.Errorf("acquire after a shorter deferral: %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"seconds", "120", now.Add(2 * time.Minute)},
+		{"zero seconds", "0", now},
+		{"HTTP date", "Mon, 01 Jan 2024 12:30:00 GMT", now.Add(30 * time.Minute)},
+		{"date in the past", "Mon, 01 Jan 2024 11:00:00 GMT", now.Add(defaultRetryAfter)},
+		{"missing", "", now.Add(defaultRetryAfter)},
+		{"garbage", "soon", now.Add(defaultRetryAfter)},
+		{"negative", "-5", now.Add(defaultRetryAfter)},
+	}
+	for _, tc := range tests {
+		header := http.Header{}
+		if tc.value != "" {
+			header.Set("Retry-After", tc.value)
+		}
+		if got := retryAfter(header, now); !got.Equal(tc.want) {
+			t.Errorf("%s: retryAfter(%q) = %v, want %v", tc.name, tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mortalglitch/gator/internal/config"
+)
+
+// newHTTPClient builds the client every request goes through from the proxy,
+// timeout, TLS and User-Agent settings in the config.
+func newHTTPClient(cfg *config.Config) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", cfg.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CABundle != "" {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read CA bundle: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if len(cfg.InsecureSkipVerifyHosts) > 0 {
+		insecure := transport.Clone()
+		if insecure.TLSClientConfig == nil {
+			insecure.TLSClientConfig = &tls.Config{}
+		}
+		insecure.TLSClientConfig.InsecureSkipVerify = true
+
+		hosts := map[string]bool{}
+		for _, host := range cfg.InsecureSkipVerifyHosts {
+			hosts[strings.ToLower(host)] = true
+		}
+		roundTripper = &hostTransport{
+			secure:        transport,
+			insecure:      insecure,
+			insecureHosts: hosts,
+		}
+	}
+
+	return &http.Client{
+		Transport: &userAgentTransport{
+			userAgent: cfg.UserAgent(),
+			next:      roundTripper,
+		},
+		Timeout: cfg.HTTPTimeout(),
+	}, nil
+}
+
+// hostTransport skips TLS verification for insecureHosts only.
+type hostTransport struct {
+	secure        http.RoundTripper
+	insecure      http.RoundTripper
+	insecureHosts map[string]bool
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.insecureHosts[strings.ToLower(req.URL.Hostname())] {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.secure.RoundTrip(req)
+}
+
+// userAgentTransport sends the configured User-Agent with every request,
+// including the ones made on redirects, unless the request already has one,
+// such as one stored for a feed.
+type userAgentTransport struct {
+	userAgent string
+	next      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") != "" {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.next.RoundTrip(req)
+}
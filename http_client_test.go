@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mortalglitch/gator/internal/config"
+)
+
+func TestHTTPClientSettings(t *testing.T) {
+	var gotAgent, gotAuth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAgent = r.Header.Get("User-Agent")
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<rss><channel><title>Private</title></channel></rss>`))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The test server's certificate is self-signed
+	fetcher, err := newFeedFetcher(&config.Config{CustomUserAgent: "gator-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fetcher.fetchFeed(context.Background(), server.URL, nil); err == nil {
+		t.Fatal("fetch from untrusted host succeeded")
+	}
+
+	fetcher, err = newFeedFetcher(&config.Config{
+		CustomUserAgent:         "gator-test",
+		InsecureSkipVerifyHosts: []string{serverURL.Hostname()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, args, err := parseCredentialFlags([]string{"--basic", "alice:secret", server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 1 || args[0] != server.URL {
+		t.Fatalf("args = %v, want the URL only", args)
+	}
+	rssFeed, err := fetcher.fetchFeed(context.Background(), server.URL, header)
+	if err != nil {
+		t.Fatalf("fetchFeed: %v", err)
+	}
+	if rssFeed.Channel.Title != "Private" {
+		t.Errorf("title = %q", rssFeed.Channel.Title)
+	}
+	if gotAgent != "gator-test" {
+		t.Errorf("User-Agent = %q, want gator-test", gotAgent)
+	}
+	if gotAuth != basicAuth("alice", "secret") {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+}
+
+func TestParseCredentialFlags(t *testing.T) {
+	header, args, err := parseCredentialFlags([]string{"--bearer", "tok", "--header", "X-Api-Key: abc", "News", "https://example.com/feed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Authorization") != "Bearer tok" || header.Get("X-Api-Key") != "abc" {
+		t.Errorf("header = %v", header)
+	}
+	if len(args) != 2 {
+		t.Errorf("args = %v, want title and url", args)
+	}
+
+	for _, bad := range [][]string{
+		{"--basic", "nocolon", "https://example.com"},
+		{"--header", "novalue", "https://example.com"},
+		{"--cookie", "a=b", "https://example.com"},
+	} {
+		if _, _, err := parseCredentialFlags(bad); err == nil {
+			t.Errorf("parseCredentialFlags(%v) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestFeedHeadersStayOnTheirHost(t *testing.T) {
+	var otherHeader http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherHeader = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<rss><channel><title>Moved</title></channel></rss>`))
+	}))
+	defer other.Close()
+
+	var sameHostKey string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			http.Redirect(w, r, "/moved", http.StatusFound)
+		case "/moved":
+			sameHostKey = r.Header.Get("X-Api-Key")
+			http.Redirect(w, r, other.URL+"/feed", http.StatusFound)
+		}
+	}))
+	defer origin.Close()
+
+	fetcher, err := newFeedFetcher(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	header.Set("X-Api-Key", "secret")
+	header.Set("Authorization", "Bearer token")
+	if _, err := fetcher.fetchFeed(context.Background(), origin.URL+"/feed", header); err != nil {
+		t.Fatal(err)
+	}
+	if sameHostKey != "secret" {
+		t.Errorf("X-Api-Key on a same host redirect = %q", sameHostKey)
+	}
+	if otherHeader.Get("X-Api-Key") != "" || otherHeader.Get("Authorization") != "" {
+		t.Errorf("credentials sent to another host: %v", otherHeader)
+	}
+
+	otherHeader = nil
+	if _, err := fetcher.fetchPage(context.Background(), origin.URL+"/feed", header); err != nil {
+		t.Fatal(err)
+	}
+	if otherHeader.Get("X-Api-Key") != "" {
+		t.Errorf("fetchPage sent X-Api-Key to another host")
+	}
+}
+
+func TestFeedUserAgentOverridesDefault(t *testing.T) {
+	var agents []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<rss><channel><title>Moved</title></channel></rss>`))
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, other.URL+"/feed", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<rss><channel><title>Feed</title></channel></rss>`))
+	}))
+	defer origin.Close()
+
+	fetcher, err := newFeedFetcher(&config.Config{CustomUserAgent: "gator-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	header.Set("User-Agent", "FeedReader/2.0")
+
+	tests := []struct {
+		url    string
+		header http.Header
+		want   []string
+	}{
+		{origin.URL + "/feed", nil, []string{"gator-test"}},
+		{origin.URL + "/feed", header, []string{"FeedReader/2.0"}},
+		// Like other feed headers it isn't sent to another host
+		{origin.URL + "/moved", header, []string{"FeedReader/2.0", "gator-test"}},
+	}
+	for _, tt := range tests {
+		agents = nil
+		if _, err := fetcher.fetchFeed(context.Background(), tt.url, tt.header); err != nil {
+			t.Fatal(err)
+		}
+		if len(agents) != len(tt.want) {
+			t.Fatalf("User-Agents for %s = %q, want %q", tt.url, agents, tt.want)
+		}
+		for i := range agents {
+			if agents[i] != tt.want[i] {
+				t.Errorf("User-Agents for %s = %q, want %q", tt.url, agents, tt.want)
+			}
+		}
+	}
+}
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Basic YWxpY2U6c2VjcmV0", "Basic ****"},
+		{"bearer abc.def.ghi", "Bearer ****"},
+		{"abc123", "****"},
+		{"my secret key", "****"},
+		{"session=abc; token=def", "****"},
+		{"", "****"},
+	}
+	for _, tt := range tests {
+		if got := maskValue(tt.value); got != tt.want {
+			t.Errorf("maskValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,174 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const configFileName = ".gatorconfig.json"
 
+const (
+	defaultRedirectThreshold  = 3
+	defaultFetchInterval      = 30 * time.Minute
+	defaultConcurrentFetches  = 4
+	defaultHostMaxConnections = 2
+	defaultHostRequestDelay   = time.Second
+	defaultMaxFeedBytes       = 10 << 20
+	defaultHTTPTimeout        = 10 * time.Second
+	defaultUserAgent          = "gator"
+	defaultListenAddr         = ":8080"
+	defaultFetchLogMaxAge     = 7 * 24 * time.Hour
+)
+
 type Config struct {
 	DBURL           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	// Number of fetches in a row that must permanently redirect to the same
+	// URL before a feed's URL is updated.
+	RedirectThreshold int `json:"redirect_threshold,omitempty"`
+	// How often feeds without their own interval are fetched, e.g. "30m".
+	DefaultFetchInterval string `json:"fetch_interval,omitempty"`
+	// How many feeds agg fetches at the same time.
+	ConcurrentFetches int `json:"concurrent_fetches,omitempty"`
+	// Politeness towards a single host: at most this many open requests,
+	// started at least this long apart, e.g. "1s".
+	HostMaxConnections int    `json:"host_max_connections,omitempty"`
+	HostRequestDelay   string `json:"host_request_delay,omitempty"`
+	// Largest feed or page gator downloads, in bytes after decompression.
+	MaxFeedSize int64 `json:"max_feed_size,omitempty"`
+	// HTTP client settings: how long a request may take, e.g. "10s", the
+	// User-Agent to send and the proxy to use, which defaults to the
+	// HTTP_PROXY/HTTPS_PROXY environment variables.
+	Timeout         string `json:"http_timeout,omitempty"`
+	CustomUserAgent string `json:"user_agent,omitempty"`
+	Proxy           string `json:"proxy,omitempty"`
+	// PEM file with CA certificates trusted on top of the system ones, and
+	// hosts whose TLS certificates aren't verified at all.
+	CABundle                string   `json:"ca_bundle,omitempty"`
+	InsecureSkipVerifyHosts []string `json:"insecure_skip_verify_hosts,omitempty"`
+	// Address gator serve listens on, and the URL it is reachable at from
+	// the outside, which WebSub hubs call back.
+	Listen    string `json:"listen_addr,omitempty"`
+	PublicURL string `json:"public_url,omitempty"`
+	// Address agg serves Prometheus metrics on, e.g. ":9090"; gator serve
+	// always has them at /metrics.
+	MetricsAddr string `json:"metrics_addr,omitempty"`
+	// Logging: debug, info, warn or error, written as text or json.
+	LogLevel  string `json:"log_level,omitempty"`
+	LogFormat string `json:"log_format,omitempty"`
+	// How long fetch log entries are kept, e.g. "168h".
+	FetchLogRetention string `json:"fetch_log_retention,omitempty"`
+	// Posts kept per feed unless the feed has its own policy: those
+	// published in the last RetentionDays days and the newest RetentionItems.
+	// 0 means no limit. Starred posts are always kept.
+	RetentionDays  int `json:"retention_days,omitempty"`
+	RetentionItems int `json:"retention_items,omitempty"`
+	// Whether agg and serve prune posts after each fetch cycle.
+	PruneAfterAgg bool `json:"prune_after_agg,omitempty"`
+}
+
+func (cfg *Config) PermanentRedirectThreshold() int {
+	if cfg.RedirectThreshold <= 0 {
+		return defaultRedirectThreshold
+	}
+	return cfg.RedirectThreshold
+}
+
+func (cfg *Config) MaxConcurrentFetches() int {
+	if cfg.ConcurrentFetches <= 0 {
+		return defaultConcurrentFetches
+	}
+	return cfg.ConcurrentFetches
+}
+
+func (cfg *Config) MaxHostConnections() int {
+	if cfg.HostMaxConnections <= 0 {
+		return defaultHostMaxConnections
+	}
+	return cfg.HostMaxConnections
+}
+
+func (cfg *Config) HostDelay() time.Duration {
+	delay, err := time.ParseDuration(cfg.HostRequestDelay)
+	if err != nil || delay < 0 {
+		return defaultHostRequestDelay
+	}
+	return delay
+}
+
+func (cfg *Config) MaxFeedBytes() int64 {
+	if cfg.MaxFeedSize <= 0 {
+		return defaultMaxFeedBytes
+	}
+	return cfg.MaxFeedSize
+}
+
+func (cfg *Config) FetchInterval() time.Duration {
+	interval, err := time.ParseDuration(cfg.DefaultFetchInterval)
+	if err != nil || interval <= 0 {
+		return defaultFetchInterval
+	}
+	return interval
+}
+
+func (cfg *Config) HTTPTimeout() time.Duration {
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil || timeout <= 0 {
+		return defaultHTTPTimeout
+	}
+	return timeout
+}
+
+func (cfg *Config) UserAgent() string {
+	if cfg.CustomUserAgent == "" {
+		return defaultUserAgent
+	}
+	return cfg.CustomUserAgent
+}
+
+func (cfg *Config) ListenAddr() string {
+	if cfg.Listen == "" {
+		return defaultListenAddr
+	}
+	return cfg.Listen
+}
+
+func (cfg *Config) FetchLogMaxAge() time.Duration {
+	maxAge, err := time.ParseDuration(cfg.FetchLogRetention)
+	if err != nil || maxAge <= 0 {
+		return defaultFetchLogMaxAge
+	}
+	return maxAge
+}
+
+// Validate checks the durations in the config, so a typo is reported
+// instead of quietly replaced by the default. Empty ones use the default.
+func (cfg *Config) Validate() error {
+	durations := []struct {
+		name      string
+		value     string
+		allowZero bool
+	}{
+		{"fetch_interval", cfg.DefaultFetchInterval, false},
+		{"host_request_delay", cfg.HostRequestDelay, true},
+		{"http_timeout", cfg.Timeout, false},
+		{"fetch_log_retention", cfg.FetchLogRetention, false},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		if duration < 0 || (duration == 0 && !d.allowZero) {
+			return fmt.Errorf("invalid %s: %q must be positive", d.name, d.value)
+		}
+	}
+	return nil
 }
 
 func (cfg *Config) SetUser(userName string) error {
@@ -36,6 +195,10 @@ func Read() (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	err = cfg.Validate()
+	if err != nil {
+		return Config{}, err
+	}
 
 	return cfg, nil
 }
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, ""},
+		{Config{DefaultFetchInterval: "45m", HostRequestDelay: "0s", Timeout: "30s", FetchLogRetention: "720h"}, ""},
+		{Config{DefaultFetchInterval: "5 mins"}, "invalid fetch_interval"},
+		{Config{DefaultFetchInterval: "0s"}, "invalid fetch_interval"},
+		{Config{HostRequestDelay: "1"}, "invalid host_request_delay"},
+		{Config{HostRequestDelay: "-1s"}, "invalid host_request_delay"},
+		{Config{Timeout: "ten seconds"}, "invalid http_timeout"},
+		{Config{FetchLogRetention: "7d"}, "invalid fetch_log_retention"},
+	}
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("Validate(%+v) = %v", tt.cfg, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.cfg, err, tt.want)
+		}
+	}
+}
+
+func TestReadRejectsInvalidDurations(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, configFileName)
+
+	err := os.WriteFile(path, []byte(`{"db_url": "sqlite://gator.db", "fetch_interval": "15m"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Read()
+	if err != nil || cfg.FetchInterval() != 15*time.Minute {
+		t.Fatalf("Read = %+v, %v", cfg, err)
+	}
+
+	err = os.WriteFile(path, []byte(`{"db_url": "sqlite://gator.db", "fetch_interval": "15 minutes"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Read()
+	if err == nil || !strings.Contains(err.Error(), `"15 minutes"`) {
+		t.Errorf("Read of an invalid fetch_interval = %v", err)
+	}
+}
@@ -91,7 +91,7 @@ func (q *Queries) DeleteUserFeed(ctx context.Context, arg DeleteUserFeedParams)
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_id, users.id, users.created_at, users.updated_at, users.name, feeds.id, feeds.created_at, feeds.updated_at, feeds.name, url, feeds.user_id, last_fetched_at,
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_id, users.id, users.created_at, users.updated_at, users.name, feeds.id, feeds.created_at, feeds.updated_at, feeds.name, url, feeds.user_id, last_fetched_at, fetch_full_text, link, description, redirect_url, redirect_count, disabled_at, fetch_interval, adaptive_interval, next_fetch_at, parsed_with_fixes, retention_days, retention_items,
   feeds.name AS feed_name,
   users.name AS user_name
 FROM feed_follows
@@ -103,24 +103,36 @@ WHERE feed_follows.user_id = $1
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	UserID        uuid.UUID
-	FeedID        uuid.UUID
-	ID_2          uuid.UUID
-	CreatedAt_2   time.Time
-	UpdatedAt_2   time.Time
-	Name          string
-	ID_3          uuid.UUID
-	CreatedAt_3   time.Time
-	UpdatedAt_3   time.Time
-	Name_2        string
-	Url           string
-	UserID_2      uuid.UUID
-	LastFetchedAt sql.NullTime
-	FeedName      string
-	UserName      string
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	UserID           uuid.UUID
+	FeedID           uuid.UUID
+	ID_2             uuid.UUID
+	CreatedAt_2      time.Time
+	UpdatedAt_2      time.Time
+	Name             string
+	ID_3             uuid.UUID
+	CreatedAt_3      time.Time
+	UpdatedAt_3      time.Time
+	Name_2           string
+	Url              string
+	UserID_2         uuid.UUID
+	LastFetchedAt    sql.NullTime
+	FetchFullText    bool
+	Link             string
+	Description      string
+	RedirectUrl      string
+	RedirectCount    int32
+	DisabledAt       sql.NullTime
+	FetchInterval    int32
+	AdaptiveInterval bool
+	NextFetchAt      sql.NullTime
+	ParsedWithFixes  bool
+	RetentionDays    sql.NullInt32
+	RetentionItems   sql.NullInt32
+	FeedName         string
+	UserName         string
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -149,6 +161,18 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.Url,
 			&i.UserID_2,
 			&i.LastFetchedAt,
+			&i.FetchFullText,
+			&i.Link,
+			&i.Description,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.DisabledAt,
+			&i.FetchInterval,
+			&i.AdaptiveInterval,
+			&i.NextFetchAt,
+			&i.ParsedWithFixes,
+			&i.RetentionDays,
+			&i.RetentionItems,
 			&i.FeedName,
 			&i.UserName,
 		); err != nil {
@@ -164,3 +188,23 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = $1
+WHERE feed_follows.feed_id = $2
+AND feed_follows.user_id NOT IN (
+  SELECT existing.user_id FROM feed_follows existing
+  WHERE existing.feed_id = $1
+)
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
@@ -14,25 +14,29 @@ import (
 )
 
 const addFeed = `-- name: AddFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id) 
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, link, description) 
 VALUES ( 
   $1,
   $2,
   $3,
   $4,
   $5,
-  $6
+  $6,
+  $7,
+  $8
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_text, link, description, redirect_url, redirect_count, disabled_at, fetch_interval, adaptive_interval, next_fetch_at, parsed_with_fixes, retention_days, retention_items
 `
 
 type AddFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         string
+	UserID      uuid.UUID
+	Link        string
+	Description string
 }
 
 func (q *Queries) AddFeed(ctx context.Context, arg AddFeedParams) (Feed, error) {
@@ -43,6 +47,8 @@ func (q *Queries) AddFeed(ctx context.Context, arg AddFeedParams) (Feed, error)
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.Link,
+		arg.Description,
 	)
 	var i Feed
 	err := row.Scan(
@@ -53,12 +59,79 @@ func (q *Queries) AddFeed(ctx context.Context, arg AddFeedParams) (Feed, error)
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Link,
+		&i.Description,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DisabledAt,
+		&i.FetchInterval,
+		&i.AdaptiveInterval,
+		&i.NextFetchAt,
+		&i.ParsedWithFixes,
+		&i.RetentionDays,
+		&i.RetentionItems,
+	)
+	return i, err
+}
+
+const countDueFeeds = `-- name: CountDueFeeds :one
+SELECT count(*) FROM feeds
+WHERE disabled_at IS NULL
+AND (next_fetch_at IS NULL OR next_fetch_at <= $1)
+`
+
+func (q *Queries) CountDueFeeds(ctx context.Context, nextFetchAt sql.NullTime) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDueFeeds, nextFetchAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const getFeed = `-- name: GetFeed :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_text, link, description, redirect_url, redirect_count, disabled_at, fetch_interval, adaptive_interval, next_fetch_at, parsed_with_fixes, retention_days, retention_items FROM feeds
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeed, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Link,
+		&i.Description,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DisabledAt,
+		&i.FetchInterval,
+		&i.AdaptiveInterval,
+		&i.NextFetchAt,
+		&i.ParsedWithFixes,
+		&i.RetentionDays,
+		&i.RetentionItems,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_text, link, description, redirect_url, redirect_count, disabled_at, fetch_interval, adaptive_interval, next_fetch_at, parsed_with_fixes, retention_days, retention_items FROM feeds
 WHERE url = $1 LIMIT 1
 `
 
@@ -73,12 +146,24 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Link,
+		&i.Description,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DisabledAt,
+		&i.FetchInterval,
+		&i.AdaptiveInterval,
+		&i.NextFetchAt,
+		&i.ParsedWithFixes,
+		&i.RetentionDays,
+		&i.RetentionItems,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_text, link, description, redirect_url, redirect_count, disabled_at, fetch_interval, adaptive_interval, next_fetch_at, parsed_with_fixes, retention_days, retention_items FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -98,6 +183,18 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.FetchFullText,
+			&i.Link,
+			&i.Description,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.DisabledAt,
+			&i.FetchInterval,
+			&i.AdaptiveInterval,
+			&i.NextFetchAt,
+			&i.ParsedWithFixes,
+			&i.RetentionDays,
+			&i.RetentionItems,
 		); err != nil {
 			return nil, err
 		}
@@ -112,25 +209,108 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at NULLS FIRST
-LIMIT 1
+const getFeedsByName = `-- name: GetFeedsByName :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_text, link, description, redirect_url, redirect_count, disabled_at, fetch_interval, adaptive_interval, next_fetch_at, parsed_with_fixes, retention_days, retention_items FROM feeds
+WHERE lower(name) = lower($1)
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
-	)
-	return i, err
+func (q *Queries) GetFeedsByName(ctx context.Context, lower string) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByName, lower)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.FetchFullText,
+			&i.Link,
+			&i.Description,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.DisabledAt,
+			&i.FetchInterval,
+			&i.AdaptiveInterval,
+			&i.NextFetchAt,
+			&i.ParsedWithFixes,
+			&i.RetentionDays,
+			&i.RetentionItems,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsToFetch = `-- name: GetFeedsToFetch :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, fetch_full_text, link, description, redirect_url, redirect_count, disabled_at, fetch_interval, adaptive_interval, next_fetch_at, parsed_with_fixes, retention_days, retention_items FROM feeds
+WHERE disabled_at IS NULL
+AND (next_fetch_at IS NULL OR next_fetch_at <= $1)
+ORDER BY next_fetch_at NULLS FIRST, last_fetched_at NULLS FIRST
+LIMIT $2
+`
+
+type GetFeedsToFetchParams struct {
+	NextFetchAt sql.NullTime
+	Limit       int32
+}
+
+func (q *Queries) GetFeedsToFetch(ctx context.Context, arg GetFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsToFetch, arg.NextFetchAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.FetchFullText,
+			&i.Link,
+			&i.Description,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.DisabledAt,
+			&i.FetchInterval,
+			&i.AdaptiveInterval,
+			&i.NextFetchAt,
+			&i.ParsedWithFixes,
+			&i.RetentionDays,
+			&i.RetentionItems,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :exec
@@ -148,3 +328,155 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.ID)
 	return err
 }
+
+const recordFeedRedirect = `-- name: RecordFeedRedirect :exec
+UPDATE feeds
+SET redirect_url = $1, redirect_count = $2, updated_at = $3
+WHERE id = $4
+`
+
+type RecordFeedRedirectParams struct {
+	RedirectUrl   string
+	RedirectCount int32
+	UpdatedAt     time.Time
+	ID            uuid.UUID
+}
+
+func (q *Queries) RecordFeedRedirect(ctx context.Context, arg RecordFeedRedirectParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedRedirect,
+		arg.RedirectUrl,
+		arg.RedirectCount,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const scheduleFeedFetch = `-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = $1
+WHERE id = $2
+`
+
+type ScheduleFeedFetchParams struct {
+	NextFetchAt sql.NullTime
+	ID          uuid.UUID
+}
+
+func (q *Queries) ScheduleFeedFetch(ctx context.Context, arg ScheduleFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleFeedFetch, arg.NextFetchAt, arg.ID)
+	return err
+}
+
+const setFeedDisabled = `-- name: SetFeedDisabled :exec
+UPDATE feeds
+SET disabled_at = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedDisabledParams struct {
+	DisabledAt sql.NullTime
+	UpdatedAt  time.Time
+	ID         uuid.UUID
+}
+
+func (q *Queries) SetFeedDisabled(ctx context.Context, arg SetFeedDisabledParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedDisabled, arg.DisabledAt, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedFullText = `-- name: SetFeedFullText :exec
+UPDATE feeds
+SET fetch_full_text = $1, updated_at = $2
+WHERE id = $3
+`
+
+type SetFeedFullTextParams struct {
+	FetchFullText bool
+	UpdatedAt     time.Time
+	ID            uuid.UUID
+}
+
+func (q *Queries) SetFeedFullText(ctx context.Context, arg SetFeedFullTextParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFullText, arg.FetchFullText, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedParsedWithFixes = `-- name: SetFeedParsedWithFixes :exec
+UPDATE feeds
+SET parsed_with_fixes = $1
+WHERE id = $2
+`
+
+type SetFeedParsedWithFixesParams struct {
+	ParsedWithFixes bool
+	ID              uuid.UUID
+}
+
+func (q *Queries) SetFeedParsedWithFixes(ctx context.Context, arg SetFeedParsedWithFixesParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedParsedWithFixes, arg.ParsedWithFixes, arg.ID)
+	return err
+}
+
+const setFeedInterval = `-- name: SetFeedInterval :exec
+UPDATE feeds
+SET fetch_interval = $1, adaptive_interval = $2, next_fetch_at = NULL, updated_at = $3
+WHERE id = $4
+`
+
+type SetFeedIntervalParams struct {
+	FetchInterval    int32
+	AdaptiveInterval bool
+	UpdatedAt        time.Time
+	ID               uuid.UUID
+}
+
+func (q *Queries) SetFeedInterval(ctx context.Context, arg SetFeedIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedInterval,
+		arg.FetchInterval,
+		arg.AdaptiveInterval,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $1, updated_at = $2, redirect_url = '', redirect_count = 0
+WHERE id = $3
+`
+
+type UpdateFeedURLParams struct {
+	Url       string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.Url, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_days = $1, retention_items = $2, updated_at = $3
+WHERE id = $4
+`
+
+type SetFeedRetentionParams struct {
+	RetentionDays  sql.NullInt32
+	RetentionItems sql.NullInt32
+	UpdatedAt      time.Time
+	ID             uuid.UUID
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention,
+		arg.RetentionDays,
+		arg.RetentionItems,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
@@ -11,14 +11,45 @@ import (
 	"github.com/google/uuid"
 )
 
+type Enclosure struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	PostID    uuid.UUID
+	Url       string
+	MimeType  string
+	Length    int64
+	Duration  int32
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             string
+	Url              string
+	UserID           uuid.UUID
+	LastFetchedAt    sql.NullTime
+	FetchFullText    bool
+	Link             string
+	Description      string
+	RedirectUrl      string
+	RedirectCount    int32
+	DisabledAt       sql.NullTime
+	FetchInterval    int32
+	AdaptiveInterval bool
+	NextFetchAt      sql.NullTime
+	ParsedWithFixes  bool
+	RetentionDays    sql.NullInt32
+	RetentionItems   sql.NullInt32
+}
+
+type FeedEvent struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	FeedID    uuid.UUID
+	Kind      string
+	Message   string
 }
 
 type FeedFollow struct {
@@ -29,6 +60,27 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 }
 
+type FeedHeader struct {
+	FeedID    uuid.UUID
+	Name      string
+	Value     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type FetchLog struct {
+	ID           uuid.UUID
+	FeedID       uuid.UUID
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	StatusCode   int32
+	Bytes        int64
+	ItemsSeen    int32
+	ItemsNew     int32
+	ItemsUpdated int32
+	Error        string
+}
+
 type Post struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
@@ -38,6 +90,13 @@ type Post struct {
 	Description string
 	PublishedAt time.Time
 	FeedID      uuid.UUID
+	Content     string
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type User struct {
@@ -46,3 +105,14 @@ type User struct {
 	UpdatedAt time.Time
 	Name      string
 }
+
+type WebsubSubscription struct {
+	FeedID         uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Hub            string
+	Topic          string
+	Secret         string
+	RequestedAt    time.Time
+	LeaseExpiresAt sql.NullTime
+}
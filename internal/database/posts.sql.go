@@ -7,13 +7,31 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countNewerPosts = `-- name: CountNewerPosts :one
+SELECT count(*) FROM posts
+WHERE feed_id = $1 AND published_at > $2
+`
+
+type CountNewerPostsParams struct {
+	FeedID      uuid.UUID
+	PublishedAt time.Time
+}
+
+func (q *Queries) CountNewerPosts(ctx context.Context, arg CountNewerPostsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countNewerPosts, arg.FeedID, arg.PublishedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id) 
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content) 
 VALUES ( 
   $1,
   $2,
@@ -22,9 +40,10 @@ VALUES (
   $5,
   $6,
   $7,
-  $8
+  $8,
+  $9
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, content
 `
 
 type CreatePostParams struct {
@@ -36,6 +55,7 @@ type CreatePostParams struct {
 	Description string
 	PublishedAt time.Time
 	FeedID      uuid.UUID
+	Content     string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -48,6 +68,7 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Content,
 	)
 	var i Post
 	err := row.Scan(
@@ -59,12 +80,67 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Content,
+	)
+	return i, err
+}
+
+const deletePost = `-- name: DeletePost :exec
+DELETE FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) DeletePost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePost, id)
+	return err
+}
+
+const getPost = `-- name: GetPost :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPost(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPost, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
+	)
+	return i, err
+}
+
+const getPostByURL = `-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts
+WHERE url = $1 LIMIT 1
+`
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByURL, url)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
 	)
 	return i, err
 }
 
 const getPostForUser = `-- name: GetPostForUser :many
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts
 ORDER BY published_at ASC
 LIMIT $1
 `
@@ -87,6 +163,197 @@ func (q *Queries) GetPostForUser(ctx context.Context, limit int32) ([]Post, erro
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Content,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPosts = `-- name: GetPosts :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentPostDates = `-- name: GetRecentPostDates :many
+SELECT published_at FROM posts
+WHERE feed_id = $1
+ORDER BY published_at DESC
+LIMIT $2
+`
+
+type GetRecentPostDatesParams struct {
+	FeedID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetRecentPostDates(ctx context.Context, arg GetRecentPostDatesParams) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostDates, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var published_at time.Time
+		if err := rows.Scan(&published_at); err != nil {
+			return nil, err
+		}
+		items = append(items, published_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = $1
+WHERE feed_id = $2
+`
+
+type MovePostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const updatePostContent = `-- name: UpdatePostContent :exec
+UPDATE posts
+SET title = $1, description = $2, content = $3, updated_at = $4
+WHERE id = $5
+`
+
+type UpdatePostContentParams struct {
+	Title       string
+	Description string
+	Content     string
+	UpdatedAt   time.Time
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostContent,
+		arg.Title,
+		arg.Description,
+		arg.Content,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const updatePostURL = `-- name: UpdatePostURL :exec
+UPDATE posts
+SET url = $1, updated_at = $2
+WHERE id = $3
+`
+
+type UpdatePostURLParams struct {
+	Url       string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdatePostURL(ctx context.Context, arg UpdatePostURLParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostURL, arg.Url, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const getPrunablePosts = `-- name: GetPrunablePosts :many
+SELECT id, title, url, published_at FROM (
+  SELECT id, title, url, published_at,
+    row_number() OVER (ORDER BY published_at DESC, created_at DESC) AS position
+  FROM posts
+  WHERE feed_id = $1
+) AS ranked
+WHERE (
+  ($2::timestamp IS NOT NULL AND published_at < $2)
+  OR ($3::int > 0 AND position > $3::int)
+)
+AND NOT EXISTS (
+  SELECT 1 FROM post_stars
+  WHERE post_stars.post_id = ranked.id
+)
+ORDER BY published_at ASC
+`
+
+type GetPrunablePostsParams struct {
+	FeedID          uuid.UUID
+	PublishedBefore sql.NullTime
+	KeepItems       int32
+}
+
+type GetPrunablePostsRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt time.Time
+}
+
+func (q *Queries) GetPrunablePosts(ctx context.Context, arg GetPrunablePostsParams) ([]GetPrunablePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPrunablePosts, arg.FeedID, arg.PublishedBefore, arg.KeepItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPrunablePostsRow
+	for rows.Next() {
+		var i GetPrunablePostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
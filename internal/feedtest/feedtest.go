@@ -0,0 +1,230 @@
+// Package feedtest serves fixture feeds over HTTP for tests. Each path
+// follows a script of responses, one per request, so a test can have a feed
+// change, fail or move between fetches.
+package feedtest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+//go:embed fixtures/*.xml
+var fixtures embed.FS
+
+// Fixture returns one of the feeds in the fixtures directory: rss2.xml,
+// atom.xml, broken.xml (needs lenient parsing) or truncated.xml (can't be
+// parsed at all).
+func Fixture(name string) []byte {
+	dat, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("feedtest: no fixture %s", name))
+	}
+	return dat
+}
+
+// Response is one scripted response.
+type Response struct {
+	// Status defaults to 200 OK.
+	Status int
+	Header http.Header
+	Body   []byte
+	// Delay is how long to wait before answering. The wait ends early when
+	// the client gives up.
+	Delay time.Duration
+	// Pad streams this many bytes of whitespace after Body, for responses
+	// too large to keep in memory.
+	Pad int64
+	// Chunked leaves out Content-Length so the size is only known once the
+	// body has been read.
+	Chunked bool
+}
+
+// OK serves body as an RSS feed.
+func OK(body []byte) Response {
+	return Response{Header: http.Header{"Content-Type": {"application/rss+xml; charset=utf-8"}}, Body: body}
+}
+
+// Atom serves body as an Atom feed.
+func Atom(body []byte) Response {
+	return Response{Header: http.Header{"Content-Type": {"application/atom+xml; charset=utf-8"}}, Body: body}
+}
+
+// Status answers with an error status and an empty body. header holds
+// pairs of names and values, such as "Retry-After", "120".
+func Status(code int, header ...string) Response {
+	r := Response{Status: code, Header: http.Header{}}
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Add(header[i], header[i+1])
+	}
+	return r
+}
+
+// Redirect answers with a redirect to location, which may be a path on
+// the same server.
+func Redirect(code int, location string) Response {
+	return Status(code, "Location", location)
+}
+
+// Slow delays r.
+func Slow(delay time.Duration, r Response) Response {
+	r.Delay = delay
+	return r
+}
+
+// Huge serves an empty RSS feed followed by size bytes of whitespace. When
+// chunked is false the size is announced in Content-Length.
+func Huge(size int64, chunked bool) Response {
+	r := OK([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Huge</title></channel></rss>`))
+	r.Pad = size
+	r.Chunked = chunked
+	return r
+}
+
+// Item is an item of a feed built with RSS.
+type Item struct {
+	Title       string
+	Link        string
+	Description string
+	Published   time.Time
+}
+
+// RSS builds an RSS 2.0 feed, for scripts where the items change from one
+// fetch to the next.
+func RSS(title string, items ...Item) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>%s</title>
+`, html.EscapeString(title))
+	for _, item := range items {
+		fmt.Fprintf(&b, "<item><title>%s</title><link>%s</link><description>%s</description>",
+			html.EscapeString(item.Title), html.EscapeString(item.Link), html.EscapeString(item.Description))
+		if !item.Published.IsZero() {
+			fmt.Fprintf(&b, "<pubDate>%s</pubDate>", item.Published.UTC().Format(time.RFC1123Z))
+		}
+		b.WriteString("</item>\n")
+	}
+	b.WriteString("</channel></rss>\n")
+	return b.Bytes()
+}
+
+// Request is what the server saw of a request.
+type Request struct {
+	Path   string
+	Header http.Header
+	// Time is when the request arrived.
+	Time time.Time
+}
+
+// Server is an httptest server answering each path from its script.
+// Requests for paths without a script get 404 Not Found.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	scripts  map[string][]Response
+	served   map[string]int
+	requests []Request
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		scripts: map[string][]Response{},
+		served:  map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Script sets the responses for path, replacing any earlier script, and
+// returns the URL to fetch. Each request gets the next response; the last
+// one is repeated once the script runs out.
+func (s *Server) Script(path string, responses ...Response) string {
+	if len(responses) == 0 {
+		panic("feedtest: empty script")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[path] = responses
+	s.served[path] = 0
+	return s.URL + path
+}
+
+// Requests returns the requests made for path so far.
+func (s *Server) Requests(path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var requests []Request
+	for _, r := range s.requests {
+		if r.Path == path {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func (s *Server) next(r *http.Request) (Response, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Path: r.URL.Path, Header: r.Header.Clone(), Time: time.Now()})
+	script, ok := s.scripts[r.URL.Path]
+	if !ok {
+		return Response{}, false
+	}
+	i := min(s.served[r.URL.Path], len(script)-1)
+	s.served[r.URL.Path]++
+	return script[i], true
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	resp, ok := s.next(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	if !resp.Chunked {
+		w.Header().Set("Content-Length", strconv.FormatInt(int64(len(resp.Body))+resp.Pad, 10))
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	w.Write(resp.Body)
+	if resp.Pad > 0 {
+		io.Copy(w, io.LimitReader(padding{}, resp.Pad))
+	}
+}
+
+// padding is endless whitespace.
+type padding struct{}
+
+func (padding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	return len(p), nil
+}
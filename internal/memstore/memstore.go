@@ -0,0 +1,944 @@
+// Package memstore keeps gator's data in memory, for tests. It behaves like
+// the SQL queries it stands in for, including unique constraints and
+// cascading deletes, but nothing is persisted.
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+// Store is an in-memory database.Querier. Rows are kept in insertion order,
+// which is the order queries without ORDER BY return them in.
+type Store struct {
+	mu         sync.Mutex
+	users      []database.User
+	feeds      []database.Feed
+	follows    []database.FeedFollow
+	posts      []database.Post
+	enclosures []database.Enclosure
+	events     []database.FeedEvent
+	headers    []database.FeedHeader
+	websub     []database.WebsubSubscription
+	fetchLog   []database.FetchLog
+	stars      []database.PostStar
+}
+
+var _ database.Querier = (*Store)(nil)
+
+func New() *Store {
+	return &Store{}
+}
+
+func errUnique(table, column, value string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint on %s.%s: %s", table, column, value)
+}
+
+func errForeignKey(table, column string, id uuid.UUID) error {
+	return fmt.Errorf("insert into %s violates foreign key constraint on %s: %v", table, column, id)
+}
+
+// The helpers below expect s.mu to be held.
+
+func (s *Store) user(id uuid.UUID) (*database.User, bool) {
+	for i := range s.users {
+		if s.users[i].ID == id {
+			return &s.users[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) feed(id uuid.UUID) (*database.Feed, bool) {
+	for i := range s.feeds {
+		if s.feeds[i].ID == id {
+			return &s.feeds[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) post(id uuid.UUID) (*database.Post, bool) {
+	for i := range s.posts {
+		if s.posts[i].ID == id {
+			return &s.posts[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) subscription(feedID uuid.UUID) (*database.WebsubSubscription, bool) {
+	for i := range s.websub {
+		if s.websub[i].FeedID == feedID {
+			return &s.websub[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) starred(postID uuid.UUID) bool {
+	for _, star := range s.stars {
+		if star.PostID == postID {
+			return true
+		}
+	}
+	return false
+}
+
+// filter keeps the items keep returns true for.
+func filter[T any](items []T, keep func(T) bool) []T {
+	var kept []T
+	for _, item := range items {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func (s *Store) deletePosts(match func(database.Post) bool) {
+	deleted := map[uuid.UUID]bool{}
+	s.posts = filter(s.posts, func(post database.Post) bool {
+		if match(post) {
+			deleted[post.ID] = true
+			return false
+		}
+		return true
+	})
+	s.enclosures = filter(s.enclosures, func(e database.Enclosure) bool { return !deleted[e.PostID] })
+	s.stars = filter(s.stars, func(star database.PostStar) bool { return !deleted[star.PostID] })
+}
+
+func (s *Store) deleteFeeds(match func(database.Feed) bool) {
+	deleted := map[uuid.UUID]bool{}
+	s.feeds = filter(s.feeds, func(feed database.Feed) bool {
+		if match(feed) {
+			deleted[feed.ID] = true
+			return false
+		}
+		return true
+	})
+	s.follows = filter(s.follows, func(f database.FeedFollow) bool { return !deleted[f.FeedID] })
+	s.events = filter(s.events, func(e database.FeedEvent) bool { return !deleted[e.FeedID] })
+	s.headers = filter(s.headers, func(h database.FeedHeader) bool { return !deleted[h.FeedID] })
+	s.websub = filter(s.websub, func(w database.WebsubSubscription) bool { return !deleted[w.FeedID] })
+	s.fetchLog = filter(s.fetchLog, func(l database.FetchLog) bool { return !deleted[l.FeedID] })
+	s.deletePosts(func(post database.Post) bool { return deleted[post.FeedID] })
+}
+
+// Users
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Name == arg.Name {
+			return database.User{}, errUnique("users", "name", arg.Name)
+		}
+	}
+	user := database.User(arg)
+	s.users = append(s.users, user)
+	return user, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, name string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, ok := s.user(id); ok {
+		return *user, nil
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUsers(ctx context.Context) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]database.User(nil), s.users...), nil
+}
+
+// DeleteUsers empties the store: every other row belongs to a user, so it
+// cascades to everything.
+func (s *Store) DeleteUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = nil
+	s.deleteFeeds(func(database.Feed) bool { return true })
+	s.follows = nil
+	s.stars = nil
+	return nil
+}
+
+// Feeds
+
+func (s *Store) AddFeed(ctx context.Context, arg database.AddFeedParams) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.user(arg.UserID); !ok {
+		return database.Feed{}, errForeignKey("feeds", "user_id", arg.UserID)
+	}
+	for _, feed := range s.feeds {
+		if feed.Url == arg.Url {
+			return database.Feed{}, errUnique("feeds", "url", arg.Url)
+		}
+	}
+	feed := database.Feed{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Name:        arg.Name,
+		Url:         arg.Url,
+		UserID:      arg.UserID,
+		Link:        arg.Link,
+		Description: arg.Description,
+	}
+	s.feeds = append(s.feeds, feed)
+	return feed, nil
+}
+
+func (s *Store) GetFeed(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if feed, ok := s.feed(id); ok {
+		return *feed, nil
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (s *Store) GetFeedByURL(ctx context.Context, url string) (database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, feed := range s.feeds {
+		if feed.Url == url {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (s *Store) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]database.Feed(nil), s.feeds...), nil
+}
+
+func (s *Store) GetFeedsByName(ctx context.Context, name string) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.feeds, func(feed database.Feed) bool {
+		return strings.EqualFold(feed.Name, name)
+	}), nil
+}
+
+func due(feed database.Feed, now sql.NullTime) bool {
+	if feed.DisabledAt.Valid {
+		return false
+	}
+	return !feed.NextFetchAt.Valid || (now.Valid && !feed.NextFetchAt.Time.After(now.Time))
+}
+
+// nullsFirst orders NULL before any time, like ORDER BY ... NULLS FIRST.
+func nullsFirst(a, b sql.NullTime) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return -1
+	case !b.Valid:
+		return 1
+	}
+	return a.Time.Compare(b.Time)
+}
+
+func (s *Store) GetFeedsToFetch(ctx context.Context, arg database.GetFeedsToFetchParams) ([]database.Feed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	feeds := filter(s.feeds, func(feed database.Feed) bool { return due(feed, arg.NextFetchAt) })
+	sort.SliceStable(feeds, func(i, j int) bool {
+		if c := nullsFirst(feeds[i].NextFetchAt, feeds[j].NextFetchAt); c != 0 {
+			return c < 0
+		}
+		return nullsFirst(feeds[i].LastFetchedAt, feeds[j].LastFetchedAt) < 0
+	})
+	if len(feeds) > int(arg.Limit) {
+		feeds = feeds[:arg.Limit]
+	}
+	return feeds, nil
+}
+
+func (s *Store) CountDueFeeds(ctx context.Context, nextFetchAt sql.NullTime) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, feed := range s.feeds {
+		if due(feed, nextFetchAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// updateFeed applies update to the feed with the given ID, if there is one.
+func (s *Store) updateFeed(id uuid.UUID, update func(*database.Feed)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if feed, ok := s.feed(id); ok {
+		update(feed)
+	}
+	return nil
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.LastFetchedAt = arg.LastFetchedAt
+		feed.UpdatedAt = arg.LastFetchedAt.Time
+	})
+}
+
+func (s *Store) SetFeedFullText(ctx context.Context, arg database.SetFeedFullTextParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.FetchFullText = arg.FetchFullText
+		feed.UpdatedAt = arg.UpdatedAt
+	})
+}
+
+func (s *Store) SetFeedParsedWithFixes(ctx context.Context, arg database.SetFeedParsedWithFixesParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.ParsedWithFixes = arg.ParsedWithFixes
+	})
+}
+
+func (s *Store) UpdateFeedURL(ctx context.Context, arg database.UpdateFeedURLParams) error {
+	s.mu.Lock()
+	for _, feed := range s.feeds {
+		if feed.Url == arg.Url && feed.ID != arg.ID {
+			s.mu.Unlock()
+			return errUnique("feeds", "url", arg.Url)
+		}
+	}
+	s.mu.Unlock()
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.Url = arg.Url
+		feed.UpdatedAt = arg.UpdatedAt
+		feed.RedirectUrl = ""
+		feed.RedirectCount = 0
+	})
+}
+
+func (s *Store) RecordFeedRedirect(ctx context.Context, arg database.RecordFeedRedirectParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.RedirectUrl = arg.RedirectUrl
+		feed.RedirectCount = arg.RedirectCount
+		feed.UpdatedAt = arg.UpdatedAt
+	})
+}
+
+func (s *Store) SetFeedDisabled(ctx context.Context, arg database.SetFeedDisabledParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.DisabledAt = arg.DisabledAt
+		feed.UpdatedAt = arg.UpdatedAt
+	})
+}
+
+func (s *Store) ScheduleFeedFetch(ctx context.Context, arg database.ScheduleFeedFetchParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.NextFetchAt = arg.NextFetchAt
+	})
+}
+
+func (s *Store) SetFeedInterval(ctx context.Context, arg database.SetFeedIntervalParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.FetchInterval = arg.FetchInterval
+		feed.AdaptiveInterval = arg.AdaptiveInterval
+		feed.NextFetchAt = sql.NullTime{}
+		feed.UpdatedAt = arg.UpdatedAt
+	})
+}
+
+func (s *Store) SetFeedRetention(ctx context.Context, arg database.SetFeedRetentionParams) error {
+	return s.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.RetentionDays = arg.RetentionDays
+		feed.RetentionItems = arg.RetentionItems
+		feed.UpdatedAt = arg.UpdatedAt
+	})
+}
+
+func (s *Store) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteFeeds(func(feed database.Feed) bool { return feed.ID == id })
+	return nil
+}
+
+// Feed follows
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.user(arg.UserID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, errForeignKey("feed_follows", "user_id", arg.UserID)
+	}
+	feed, ok := s.feed(arg.FeedID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, errForeignKey("feed_follows", "feed_id", arg.FeedID)
+	}
+	for _, follow := range s.follows {
+		if follow.UserID == arg.UserID && follow.FeedID == arg.FeedID {
+			return database.CreateFeedFollowRow{}, errUnique("feed_follows", "user_id, feed_id", fmt.Sprintf("%v, %v", arg.UserID, arg.FeedID))
+		}
+	}
+	s.follows = append(s.follows, database.FeedFollow(arg))
+	return database.CreateFeedFollowRow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+		FeedName:  feed.Name,
+		UserName:  user.Name,
+	}, nil
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFeedFollowsForUserRow
+	for _, follow := range s.follows {
+		if follow.UserID != userID {
+			continue
+		}
+		user, _ := s.user(follow.UserID)
+		feed, _ := s.feed(follow.FeedID)
+		rows = append(rows, database.GetFeedFollowsForUserRow{
+			ID:               follow.ID,
+			CreatedAt:        follow.CreatedAt,
+			UpdatedAt:        follow.UpdatedAt,
+			UserID:           follow.UserID,
+			FeedID:           follow.FeedID,
+			ID_2:             user.ID,
+			CreatedAt_2:      user.CreatedAt,
+			UpdatedAt_2:      user.UpdatedAt,
+			Name:             user.Name,
+			ID_3:             feed.ID,
+			CreatedAt_3:      feed.CreatedAt,
+			UpdatedAt_3:      feed.UpdatedAt,
+			Name_2:           feed.Name,
+			Url:              feed.Url,
+			UserID_2:         feed.UserID,
+			LastFetchedAt:    feed.LastFetchedAt,
+			FetchFullText:    feed.FetchFullText,
+			Link:             feed.Link,
+			Description:      feed.Description,
+			RedirectUrl:      feed.RedirectUrl,
+			RedirectCount:    feed.RedirectCount,
+			DisabledAt:       feed.DisabledAt,
+			FetchInterval:    feed.FetchInterval,
+			AdaptiveInterval: feed.AdaptiveInterval,
+			NextFetchAt:      feed.NextFetchAt,
+			ParsedWithFixes:  feed.ParsedWithFixes,
+			RetentionDays:    feed.RetentionDays,
+			RetentionItems:   feed.RetentionItems,
+			FeedName:         feed.Name,
+			UserName:         user.Name,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) DeleteUserFeed(ctx context.Context, arg database.DeleteUserFeedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.follows = filter(s.follows, func(follow database.FeedFollow) bool {
+		return follow.UserID != arg.UserID || follow.FeedID != arg.FeedID
+	})
+	return nil
+}
+
+// MoveFeedFollows moves follows to another feed, except those of users
+// already following it.
+func (s *Store) MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	following := map[uuid.UUID]bool{}
+	for _, follow := range s.follows {
+		if follow.FeedID == arg.ToFeedID {
+			following[follow.UserID] = true
+		}
+	}
+	for i, follow := range s.follows {
+		if follow.FeedID == arg.FromFeedID && !following[follow.UserID] {
+			s.follows[i].FeedID = arg.ToFeedID
+		}
+	}
+	return nil
+}
+
+// Feed events
+
+func (s *Store) CreateFeedEvent(ctx context.Context, arg database.CreateFeedEventParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return errForeignKey("feed_events", "feed_id", arg.FeedID)
+	}
+	s.events = append(s.events, database.FeedEvent(arg))
+	return nil
+}
+
+func (s *Store) GetFeedEventsForUser(ctx context.Context, arg database.GetFeedEventsForUserParams) ([]database.GetFeedEventsForUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	followed := map[uuid.UUID]bool{}
+	for _, follow := range s.follows {
+		if follow.UserID == arg.UserID {
+			followed[follow.FeedID] = true
+		}
+	}
+	var rows []database.GetFeedEventsForUserRow
+	for _, event := range s.events {
+		if !followed[event.FeedID] {
+			continue
+		}
+		feed, _ := s.feed(event.FeedID)
+		rows = append(rows, database.GetFeedEventsForUserRow{
+			ID:        event.ID,
+			CreatedAt: event.CreatedAt,
+			FeedID:    event.FeedID,
+			Kind:      event.Kind,
+			Message:   event.Message,
+			FeedName:  feed.Name,
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].CreatedAt.After(rows[j].CreatedAt) })
+	if len(rows) > int(arg.Limit) {
+		rows = rows[:arg.Limit]
+	}
+	return rows, nil
+}
+
+// Feed headers
+
+func (s *Store) SetFeedHeader(ctx context.Context, arg database.SetFeedHeaderParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return errForeignKey("feed_headers", "feed_id", arg.FeedID)
+	}
+	for i, header := range s.headers {
+		if header.FeedID == arg.FeedID && header.Name == arg.Name {
+			s.headers[i].Value = arg.Value
+			s.headers[i].UpdatedAt = arg.UpdatedAt
+			return nil
+		}
+	}
+	s.headers = append(s.headers, database.FeedHeader(arg))
+	return nil
+}
+
+func (s *Store) GetFeedHeaders(ctx context.Context, feedID uuid.UUID) ([]database.FeedHeader, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	headers := filter(s.headers, func(header database.FeedHeader) bool { return header.FeedID == feedID })
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+	return headers, nil
+}
+
+func (s *Store) DeleteFeedHeaders(ctx context.Context, feedID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers = filter(s.headers, func(header database.FeedHeader) bool { return header.FeedID != feedID })
+	return nil
+}
+
+// Posts
+
+func (s *Store) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return database.Post{}, errForeignKey("posts", "feed_id", arg.FeedID)
+	}
+	for _, post := range s.posts {
+		if post.Url == arg.Url {
+			return database.Post{}, errUnique("posts", "url", arg.Url)
+		}
+	}
+	post := database.Post(arg)
+	s.posts = append(s.posts, post)
+	return post, nil
+}
+
+func (s *Store) GetPost(ctx context.Context, id uuid.UUID) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if post, ok := s.post(id); ok {
+		return *post, nil
+	}
+	return database.Post{}, sql.ErrNoRows
+}
+
+func (s *Store) GetPostByURL(ctx context.Context, url string) (database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, post := range s.posts {
+		if post.Url == url {
+			return post, nil
+		}
+	}
+	return database.Post{}, sql.ErrNoRows
+}
+
+func (s *Store) GetPostForUser(ctx context.Context, limit int32) ([]database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := append([]database.Post(nil), s.posts...)
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].PublishedAt.Before(posts[j].PublishedAt) })
+	if len(posts) > int(limit) {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (s *Store) GetPosts(ctx context.Context) ([]database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := append([]database.Post(nil), s.posts...)
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].CreatedAt.Before(posts[j].CreatedAt) })
+	return posts, nil
+}
+
+func (s *Store) GetRecentPostDates(ctx context.Context, arg database.GetRecentPostDatesParams) ([]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var dates []time.Time
+	for _, post := range s.posts {
+		if post.FeedID == arg.FeedID {
+			dates = append(dates, post.PublishedAt)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].After(dates[j]) })
+	if len(dates) > int(arg.Limit) {
+		dates = dates[:arg.Limit]
+	}
+	return dates, nil
+}
+
+func (s *Store) MovePosts(ctx context.Context, arg database.MovePostsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, post := range s.posts {
+		if post.FeedID == arg.FromFeedID {
+			s.posts[i].FeedID = arg.ToFeedID
+		}
+	}
+	return nil
+}
+
+func (s *Store) UpdatePostURL(ctx context.Context, arg database.UpdatePostURLParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, post := range s.posts {
+		if post.Url == arg.Url && post.ID != arg.ID {
+			return errUnique("posts", "url", arg.Url)
+		}
+	}
+	if post, ok := s.post(arg.ID); ok {
+		post.Url = arg.Url
+		post.UpdatedAt = arg.UpdatedAt
+	}
+	return nil
+}
+
+func (s *Store) UpdatePostContent(ctx context.Context, arg database.UpdatePostContentParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if post, ok := s.post(arg.ID); ok {
+		post.Title = arg.Title
+		post.Description = arg.Description
+		post.Content = arg.Content
+		post.UpdatedAt = arg.UpdatedAt
+	}
+	return nil
+}
+
+func (s *Store) DeletePost(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletePosts(func(post database.Post) bool { return post.ID == id })
+	return nil
+}
+
+func (s *Store) CountNewerPosts(ctx context.Context, arg database.CountNewerPostsParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, post := range s.posts {
+		if post.FeedID == arg.FeedID && post.PublishedAt.After(arg.PublishedAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetPrunablePosts returns the unstarred posts of a feed published before
+// PublishedBefore or beyond the newest KeepItems, oldest first.
+func (s *Store) GetPrunablePosts(ctx context.Context, arg database.GetPrunablePostsParams) ([]database.GetPrunablePostsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := filter(s.posts, func(post database.Post) bool { return post.FeedID == arg.FeedID })
+	sort.SliceStable(posts, func(i, j int) bool {
+		if !posts[i].PublishedAt.Equal(posts[j].PublishedAt) {
+			return posts[i].PublishedAt.After(posts[j].PublishedAt)
+		}
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+	var rows []database.GetPrunablePostsRow
+	for i, post := range posts {
+		old := arg.PublishedBefore.Valid && post.PublishedAt.Before(arg.PublishedBefore.Time)
+		beyond := arg.KeepItems > 0 && i >= int(arg.KeepItems)
+		if (old || beyond) && !s.starred(post.ID) {
+			rows = append(rows, database.GetPrunablePostsRow{
+				ID:          post.ID,
+				Title:       post.Title,
+				Url:         post.Url,
+				PublishedAt: post.PublishedAt,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].PublishedAt.Before(rows[j].PublishedAt) })
+	return rows, nil
+}
+
+// Enclosures
+
+func (s *Store) CreateEnclosure(ctx context.Context, arg database.CreateEnclosureParams) (database.Enclosure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.post(arg.PostID); !ok {
+		return database.Enclosure{}, errForeignKey("enclosures", "post_id", arg.PostID)
+	}
+	for _, enclosure := range s.enclosures {
+		if enclosure.PostID == arg.PostID && enclosure.Url == arg.Url {
+			return database.Enclosure{}, errUnique("enclosures", "post_id, url", arg.Url)
+		}
+	}
+	enclosure := database.Enclosure(arg)
+	s.enclosures = append(s.enclosures, enclosure)
+	return enclosure, nil
+}
+
+func (s *Store) GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]database.Enclosure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enclosures := filter(s.enclosures, func(e database.Enclosure) bool { return e.PostID == postID })
+	sort.SliceStable(enclosures, func(i, j int) bool { return enclosures[i].CreatedAt.Before(enclosures[j].CreatedAt) })
+	return enclosures, nil
+}
+
+// Stars
+
+func (s *Store) StarPost(ctx context.Context, arg database.StarPostParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.user(arg.UserID); !ok {
+		return errForeignKey("post_stars", "user_id", arg.UserID)
+	}
+	if _, ok := s.post(arg.PostID); !ok {
+		return errForeignKey("post_stars", "post_id", arg.PostID)
+	}
+	for _, star := range s.stars {
+		if star.UserID == arg.UserID && star.PostID == arg.PostID {
+			return nil
+		}
+	}
+	s.stars = append(s.stars, database.PostStar(arg))
+	return nil
+}
+
+func (s *Store) UnstarPost(ctx context.Context, arg database.UnstarPostParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stars = filter(s.stars, func(star database.PostStar) bool {
+		return star.UserID != arg.UserID || star.PostID != arg.PostID
+	})
+	return nil
+}
+
+func (s *Store) GetStarredPostsForUser(ctx context.Context, userID uuid.UUID) ([]database.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stars := filter(s.stars, func(star database.PostStar) bool { return star.UserID == userID })
+	sort.SliceStable(stars, func(i, j int) bool { return stars[i].CreatedAt.After(stars[j].CreatedAt) })
+	var posts []database.Post
+	for _, star := range stars {
+		if post, ok := s.post(star.PostID); ok {
+			posts = append(posts, *post)
+		}
+	}
+	return posts, nil
+}
+
+// Fetch log
+
+func (s *Store) CreateFetchLog(ctx context.Context, arg database.CreateFetchLogParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return errForeignKey("fetch_log", "feed_id", arg.FeedID)
+	}
+	s.fetchLog = append(s.fetchLog, database.FetchLog(arg))
+	return nil
+}
+
+func (s *Store) GetFetchLog(ctx context.Context, arg database.GetFetchLogParams) ([]database.GetFetchLogRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []database.GetFetchLogRow
+	for _, entry := range s.fetchLog {
+		if entry.StartedAt.Before(arg.Since) || (arg.FeedID.Valid && entry.FeedID != arg.FeedID.UUID) {
+			continue
+		}
+		feed, _ := s.feed(entry.FeedID)
+		rows = append(rows, database.GetFetchLogRow{
+			ID:           entry.ID,
+			FeedID:       entry.FeedID,
+			StartedAt:    entry.StartedAt,
+			FinishedAt:   entry.FinishedAt,
+			StatusCode:   entry.StatusCode,
+			Bytes:        entry.Bytes,
+			ItemsSeen:    entry.ItemsSeen,
+			ItemsNew:     entry.ItemsNew,
+			ItemsUpdated: entry.ItemsUpdated,
+			Error:        entry.Error,
+			FeedName:     feed.Name,
+			FeedUrl:      feed.Url,
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].StartedAt.After(rows[j].StartedAt) })
+	return rows, nil
+}
+
+func (s *Store) GetRecentFetchErrors(ctx context.Context, arg database.GetRecentFetchErrorsParams) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var runs []database.FetchLog
+	for _, entry := range s.fetchLog {
+		if entry.FeedID == arg.FeedID {
+			runs = append(runs, entry)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	if len(runs) > int(arg.Limit) {
+		runs = runs[:arg.Limit]
+	}
+	errs := make([]string, len(runs))
+	for i, run := range runs {
+		errs[i] = run.Error
+	}
+	return errs, nil
+}
+
+func (s *Store) DeleteFetchLogBefore(ctx context.Context, startedAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := len(s.fetchLog)
+	s.fetchLog = filter(s.fetchLog, func(entry database.FetchLog) bool { return !entry.StartedAt.Before(startedAt) })
+	return int64(before - len(s.fetchLog)), nil
+}
+
+// WebSub subscriptions
+
+func (s *Store) SaveWebSubSubscription(ctx context.Context, arg database.SaveWebSubSubscriptionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.feed(arg.FeedID); !ok {
+		return errForeignKey("websub_subscriptions", "feed_id", arg.FeedID)
+	}
+	if sub, ok := s.subscription(arg.FeedID); ok {
+		sub.UpdatedAt = arg.UpdatedAt
+		sub.Hub = arg.Hub
+		sub.Topic = arg.Topic
+		sub.Secret = arg.Secret
+		sub.RequestedAt = arg.RequestedAt
+		sub.LeaseExpiresAt = sql.NullTime{}
+		return nil
+	}
+	s.websub = append(s.websub, database.WebsubSubscription{
+		FeedID:      arg.FeedID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Hub:         arg.Hub,
+		Topic:       arg.Topic,
+		Secret:      arg.Secret,
+		RequestedAt: arg.RequestedAt,
+	})
+	return nil
+}
+
+func (s *Store) GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (database.WebsubSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subscription(feedID); ok {
+		return *sub, nil
+	}
+	return database.WebsubSubscription{}, sql.ErrNoRows
+}
+
+func (s *Store) MarkWebSubRequested(ctx context.Context, arg database.MarkWebSubRequestedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subscription(arg.FeedID); ok {
+		sub.RequestedAt = arg.RequestedAt
+		sub.UpdatedAt = arg.RequestedAt
+	}
+	return nil
+}
+
+func (s *Store) SetWebSubLease(ctx context.Context, arg database.SetWebSubLeaseParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subscription(arg.FeedID); ok {
+		sub.LeaseExpiresAt = arg.LeaseExpiresAt
+		sub.UpdatedAt = arg.UpdatedAt
+	}
+	return nil
+}
+
+// GetWebSubSubscriptionsToRenew returns the subscriptions whose lease ends
+// before LeaseExpiresAt and that weren't requested since RequestedAt.
+// Unconfirmed subscriptions have no lease, which never compares as earlier.
+func (s *Store) GetWebSubSubscriptionsToRenew(ctx context.Context, arg database.GetWebSubSubscriptionsToRenewParams) ([]database.WebsubSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filter(s.websub, func(sub database.WebsubSubscription) bool {
+		return sub.LeaseExpiresAt.Valid && arg.LeaseExpiresAt.Valid &&
+			sub.LeaseExpiresAt.Time.Before(arg.LeaseExpiresAt.Time) &&
+			sub.RequestedAt.Before(arg.RequestedAt)
+	}), nil
+}
+
+func (s *Store) DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.websub = filter(s.websub, func(sub database.WebsubSubscription) bool { return sub.FeedID != feedID })
+	return nil
+}
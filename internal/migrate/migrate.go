@@ -0,0 +1,262 @@
+// Package migrate applies goose-annotated SQL migrations. Applied versions
+// are kept in goose's goose_db_version table, so databases set up with the
+// goose command line tool carry on where they are.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is one numbered migration file.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Dialect holds the statements that differ between database engines.
+type Dialect struct {
+	createTable string
+	insert      string
+	delete      string
+}
+
+var (
+	Postgres = Dialect{
+		createTable: `CREATE TABLE IF NOT EXISTS goose_db_version (
+  id SERIAL PRIMARY KEY,
+  version_id BIGINT NOT NULL,
+  is_applied BOOLEAN NOT NULL,
+  tstamp TIMESTAMP NULL DEFAULT now()
+)`,
+		insert: "INSERT INTO goose_db_version (version_id, is_applied, tstamp) VALUES ($1, true, $2)",
+		delete: "DELETE FROM goose_db_version WHERE version_id = $1",
+	}
+	SQLite = Dialect{
+		createTable: `CREATE TABLE IF NOT EXISTS goose_db_version (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  version_id INTEGER NOT NULL,
+  is_applied INTEGER NOT NULL,
+  tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`,
+		insert: "INSERT INTO goose_db_version (version_id, is_applied, tstamp) VALUES (?, true, ?)",
+		delete: "DELETE FROM goose_db_version WHERE version_id = ?",
+	}
+)
+
+// Migrator applies the migrations found in a directory to a database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New loads the .sql files at the root of fsys, which must be named like
+// 001_users.sql.
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Load reads and sorts the migrations at the root of fsys.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := map[int64]string{}
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s doesn't start with a version number", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, name)
+		}
+		seen[version] = name
+
+		dat, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := parse(string(dat))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse migration %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: path.Base(name), Up: up, Down: down})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// parse splits a migration at its "-- +goose Up" and "-- +goose Down"
+// annotations.
+func parse(migration string) (up, down string, err error) {
+	var sections [2]strings.Builder
+	current := -1
+	scanner := bufio.NewScanner(strings.NewReader(migration))
+	for scanner.Scan() {
+		line := scanner.Text()
+		annotation, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose ")
+		if !ok {
+			if current >= 0 {
+				sections[current].WriteString(line + "\n")
+			}
+			continue
+		}
+		switch strings.TrimSpace(annotation) {
+		case "Up":
+			current = 0
+		case "Down":
+			current = 1
+		default:
+			return "", "", fmt.Errorf("unknown annotation %q", strings.TrimSpace(line))
+		}
+	}
+	if current < 0 {
+		return "", "", errors.New("no -- +goose Up annotation")
+	}
+	return strings.TrimSpace(sections[0].String()), strings.TrimSpace(sections[1].String()), scanner.Err()
+}
+
+// Status is a migration and whether and when it was applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt sql.NullTime
+}
+
+// Status lists every migration with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		at, ok := applied[migration.Version]
+		statuses[i] = Status{Migration: migration, Applied: ok, AppliedAt: at}
+	}
+	return statuses, nil
+}
+
+// Pending lists the migrations that haven't been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order, each in its own
+// transaction, and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, migration := range pending {
+		err := m.run(ctx, migration.Up, m.dialect.insert, migration.Version, time.Now().UTC())
+		if err != nil {
+			return pending[:i], fmt.Errorf("couldn't apply %s: %w", migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the most recently applied migration. ok is false when
+// nothing is applied.
+func (m *Migrator) Down(ctx context.Context) (migration Migration, ok bool, err error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return Migration{}, false, err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration = m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.run(ctx, migration.Down, m.dialect.delete, migration.Version)
+		if err != nil {
+			return migration, false, fmt.Errorf("couldn't roll back %s: %w", migration.Name, err)
+		}
+		return migration, true, nil
+	}
+	return Migration{}, false, nil
+}
+
+// run executes a migration and records it in goose_db_version in one
+// transaction.
+func (m *Migrator) run(ctx context.Context, statements, record string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if statements != "" {
+		_, err = tx.ExecContext(ctx, statements)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, record, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied returns when each applied version was applied, creating the
+// version table if needed. goose records version 0 when it creates the
+// table, which isn't a migration.
+func (m *Migrator) applied(ctx context.Context) (map[int64]sql.NullTime, error) {
+	_, err := m.db.ExecContext(ctx, m.dialect.createTable)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create version table: %w", err)
+	}
+	rows, err := m.db.QueryContext(ctx, "SELECT version_id, tstamp FROM goose_db_version WHERE is_applied AND version_id > 0")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]sql.NullTime{}
+	for rows.Next() {
+		var version int64
+		var at sql.NullTime
+		err := rows.Scan(&version, &at)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
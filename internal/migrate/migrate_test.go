@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mortalglitch/gator/internal/sqlitedb"
+)
+
+func TestLoadSchemas(t *testing.T) {
+	for _, dir := range []string{"../../sql/schema", "../../sql/sqlite/schema"} {
+		migrations, err := Load(os.DirFS(dir))
+		if err != nil {
+			t.Errorf("%s: %v", dir, err)
+			continue
+		}
+		for i, migration := range migrations {
+			if migration.Version != int64(i+1) {
+				t.Errorf("%s: %s has version %d, want %d", dir, migration.Name, migration.Version, i+1)
+			}
+			if migration.Up == "" || migration.Down == "" {
+				t.Errorf("%s: %s is missing its Up or Down section", dir, migration.Name)
+			}
+		}
+	}
+}
+
+func TestParseRejectsUnknownAnnotations(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"001_users.sql": {Data: []byte("-- +goose up\nCREATE TABLE users(id INTEGER);\n")},
+	})
+	if err == nil {
+		t.Error("lowercase annotation accepted")
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlitedb.Open(filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrator, err := New(db, SQLite, fstest.MapFS{
+		"001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users(id INTEGER);\n\n-- +goose Down\nDROP TABLE users;\n")},
+		"002_feeds.sql": {Data: []byte("-- +goose Up\nCREATE TABLE feeds(id INTEGER);\nCREATE TABLE posts(id INTEGER);\n\n-- +goose Down\nDROP TABLE posts;\nDROP TABLE feeds;\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("Pending = %d, %v", len(pending), err)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Up = %d, %v", len(applied), err)
+	}
+	_, err = db.Exec("INSERT INTO posts (id) VALUES (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err = migrator.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("second Up = %d, %v", len(applied), err)
+	}
+
+	migration, ok, err := migrator.Down(ctx)
+	if err != nil || !ok || migration.Version != 2 {
+		t.Fatalf("Down = %v, %v, %v", migration.Name, ok, err)
+	}
+	_, err = db.Exec("SELECT * FROM posts")
+	if err == nil {
+		t.Error("posts still exists after rolling back 002")
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || !statuses[0].AppliedAt.Valid || statuses[1].Applied {
+		t.Errorf("Status = %+v", statuses)
+	}
+}
@@ -0,0 +1,256 @@
+package readability
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoContent is returned when no block of the page scores high enough to
+// be considered the article body.
+var ErrNoContent = errors.New("no readable content found")
+
+const maxPageSize = 5 << 20
+
+var (
+	unlikelyCandidates = regexp.MustCompile(`(?i)ad-|ads|advert|banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|header|menu|meta|nav|newsletter|pager|pagination|popup|promo|related|remark|rss|share|shoutbox|sidebar|social|sponsor|subscribe|tags|tool|widget`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveClass      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|story|text|blog`)
+	negativeClass      = regexp.MustCompile(`(?i)hidden|banner|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// tags that never contain article text and are dropped before scoring.
+var strippedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Svg:      true,
+	atom.Link:     true,
+	atom.Meta:     true,
+}
+
+// Fetch downloads pageURL with client, sending the extra headers in header,
+// and extracts its main content.
+func Fetch(ctx context.Context, client *http.Client, pageURL string, header http.Header) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "gator")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status fetching %s: %s", pageURL, resp.Status)
+	}
+
+	return Extract(io.LimitReader(resp.Body, maxPageSize))
+}
+
+// Extract parses an HTML document and returns the HTML of the block most
+// likely to hold the article, scored by text density in the spirit of
+// Mozilla's Readability.
+func Extract(r io.Reader) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	prune(body)
+
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(n) + tagWeight(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	walk(body, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return
+		}
+		text := strings.TrimSpace(textContent(n))
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best = n
+			bestScore = score
+		}
+	}
+	if best == nil || bestScore < 5 {
+		return "", ErrNoContent
+	}
+
+	var buf bytes.Buffer
+	for c := best.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// prune removes non-content tags and elements whose class or id mark them as
+// navigation, comments, ads and similar clutter.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode {
+			n.RemoveChild(c)
+		} else if c.Type == html.ElementNode {
+			if strippedTags[c.DataAtom] || isUnlikely(c) {
+				n.RemoveChild(c)
+			} else {
+				prune(c)
+			}
+		}
+		c = next
+	}
+}
+
+func isUnlikely(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	match := attr(n, "class") + " " + attr(n, "id")
+	if strings.TrimSpace(match) == "" {
+		return false
+	}
+	return unlikelyCandidates.MatchString(match) && !maybeCandidate.MatchString(match)
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attr(n, "class"), attr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeClass.MatchString(value) {
+			weight -= 25
+		}
+		if positiveClass.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func tagWeight(n *html.Node) float64 {
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		return 10
+	case atom.Div:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		return 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+	return 0
+}
+
+// linkDensity is the share of n's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	textLength := len(textContent(n))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	walk(n, func(c *html.Node) {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			linkLength += len(textContent(c))
+		}
+	})
+	return float64(linkLength) / float64(textLength)
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+	})
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) {
+		if found == nil && c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+		}
+	})
+	return found
+}
+
+// walk calls fn for n and every descendant in document order.
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
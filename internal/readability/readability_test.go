@@ -0,0 +1,118 @@
+package readability
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchFixtures(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "blog with sidebar and comments",
+			path: "/blog.html",
+			want: []string{
+				"Feeds are a simple, durable way",
+				"An aggregator polls each feed",
+				"keeps the ecosystem healthy",
+			},
+			notWant: []string{
+				"window.tracking",
+				"swamp insurance",
+				"Popular posts",
+				"First!",
+				"Archive",
+				"all rights reserved",
+			},
+		},
+		{
+			name: "news article with related links",
+			path: "/news.html",
+			want: []string{
+				"The county swamp reopened",
+				"restored water flow",
+				"free guided walk",
+			},
+			notWant: []string{
+				"Swamp budget approved",
+				"Subscribe to our newsletter",
+				"Sport",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := Fetch(context.Background(), server.Client(), server.URL+tc.path, nil)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			for _, s := range tc.want {
+				if !strings.Contains(content, s) {
+					t.Errorf("content missing %q:\n%s", s, content)
+				}
+			}
+			for _, s := range tc.notWant {
+				if strings.Contains(content, s) {
+					t.Errorf("content should not contain %q:\n%s", s, content)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchNoContent(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	_, err := Fetch(context.Background(), server.Client(), server.URL+"/teaser.html", nil)
+	if !errors.Is(err, ErrNoContent) {
+		t.Fatalf("expected ErrNoContent, got %v", err)
+	}
+}
+
+func TestFetchBadStatus(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	_, err := Fetch(context.Background(), server.Client(), server.URL+"/missing.html", nil)
+	if err == nil {
+		t.Fatal("expected error for missing page")
+	}
+}
+
+func TestFetchUserAgent(t *testing.T) {
+	var agent string
+	files := http.FileServer(http.Dir("testdata"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.Header.Get("User-Agent")
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	if _, err := Fetch(context.Background(), server.Client(), server.URL+"/blog.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if agent != "gator" {
+		t.Errorf("default User-Agent = %q", agent)
+	}
+
+	header := http.Header{"User-Agent": {"FeedReader/2.0"}}
+	if _, err := Fetch(context.Background(), server.Client(), server.URL+"/blog.html", header); err != nil {
+		t.Fatal(err)
+	}
+	if agent != "FeedReader/2.0" {
+		t.Errorf("User-Agent = %q, want the one passed in", agent)
+	}
+}
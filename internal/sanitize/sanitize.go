@@ -0,0 +1,246 @@
+package sanitize
+
+import (
+	"bytes"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags maps each permitted element to the attributes it may keep.
+// Elements not listed are unwrapped, keeping their children.
+var allowedTags = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Li:         nil,
+	atom.Ol:         nil,
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.S:          nil,
+	atom.Small:      nil,
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[atom.Atom]bool{
+	atom.Applet:   true,
+	atom.Audio:    true,
+	atom.Button:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Head:     true,
+	atom.Iframe:   true,
+	atom.Input:    true,
+	atom.Link:     true,
+	atom.Math:     true,
+	atom.Meta:     true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Title:    true,
+	atom.Video:    true,
+}
+
+// urlAttrs are attributes whose values are URLs and must be checked.
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// HTML returns fragment reduced to an allow-list of tags and attributes.
+// Scripts, styles, embedded objects, event handlers, non-http(s) URLs and
+// tracking pixels are removed, and relative URLs are resolved against base
+// when it is non-nil.
+func HTML(fragment string, base *url.URL) string {
+	if !strings.ContainsAny(fragment, "<&") {
+		return fragment
+	}
+
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		for _, clean := range cleanNode(n, base) {
+			html.Render(&buf, clean)
+		}
+	}
+	return buf.String()
+}
+
+// cleanNode returns the sanitized replacement for n, which is empty when n is
+// dropped and n's children when n is unwrapped.
+func cleanNode(n *html.Node, base *url.URL) []*html.Node {
+	switch n.Type {
+	case html.TextNode:
+		return []*html.Node{{Type: html.TextNode, Data: n.Data}}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	if droppedTags[n.DataAtom] {
+		return nil
+	}
+
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, cleanNode(c, base)...)
+	}
+
+	allowed, ok := allowedTags[n.DataAtom]
+	if !ok {
+		return children
+	}
+
+	clean := &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !slices.Contains(allowed, a.Key) {
+			continue
+		}
+		if urlAttrs[a.Key] {
+			resolved, ok := cleanURL(a.Val, base)
+			if !ok {
+				continue
+			}
+			a.Val = resolved
+		}
+		clean.Attr = append(clean.Attr, html.Attribute{Key: a.Key, Val: a.Val})
+	}
+
+	switch n.DataAtom {
+	case atom.Img:
+		if isTrackingPixel(clean) {
+			return nil
+		}
+		if attrValue(clean, "src") == "" {
+			return nil
+		}
+	case atom.A:
+		if attrValue(clean, "href") != "" {
+			clean.Attr = append(clean.Attr, html.Attribute{Key: "rel", Val: "noopener noreferrer nofollow"})
+		}
+	}
+
+	for _, c := range children {
+		clean.AppendChild(c)
+	}
+	return []*html.Node{clean}
+}
+
+// cleanURL resolves raw against base and reports whether the result uses an
+// allowed scheme.
+func cleanURL(raw string, base *url.URL) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme == "" {
+		if base == nil {
+			// Keep relative references we can't resolve, they can't
+			// carry a scheme like javascript:.
+			return u.String(), true
+		}
+		u = base.ResolveReference(u)
+	}
+	if !allowedSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// isTrackingPixel reports whether img is sized to be invisible.
+func isTrackingPixel(img *html.Node) bool {
+	for _, key := range []string{"width", "height"} {
+		value := strings.TrimSuffix(attrValue(img, key), "px")
+		if value == "" {
+			continue
+		}
+		size, err := strconv.Atoi(value)
+		if err == nil && size <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// ResolveURL resolves ref against base, returning ref unchanged when either
+// can't be parsed.
+func ResolveURL(ref string, base *url.URL) string {
+	if base == nil {
+		return ref
+	}
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
@@ -0,0 +1,89 @@
+package sanitize
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post-1")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain text untouched",
+			in:   "Just a summary.",
+			want: "Just a summary.",
+		},
+		{
+			name: "script removed with contents",
+			in:   `<p>Hi</p><script>alert(1)</script>`,
+			want: `<p>Hi</p>`,
+		},
+		{
+			name: "event handlers stripped",
+			in:   `<p onclick="steal()">Hi</p>`,
+			want: `<p>Hi</p>`,
+		},
+		{
+			name: "javascript url dropped",
+			in:   `<a href="javascript:alert(1)">x</a>`,
+			want: `<a>x</a>`,
+		},
+		{
+			name: "relative link resolved",
+			in:   `<a href="../about">About</a>`,
+			want: `<a href="https://example.com/about" rel="noopener noreferrer nofollow">About</a>`,
+		},
+		{
+			name: "relative image resolved",
+			in:   `<img src="/img/a.png" alt="A">`,
+			want: `<img src="https://example.com/img/a.png" alt="A"/>`,
+		},
+		{
+			name: "tracking pixel removed",
+			in:   `<p>Text<img src="https://t.example/p.gif" width="1" height="1"></p>`,
+			want: `<p>Text</p>`,
+		},
+		{
+			name: "unknown tags unwrapped",
+			in:   `<section><font color="red">Hi</font></section>`,
+			want: `Hi`,
+		},
+		{
+			name: "iframe and style dropped",
+			in:   `<iframe src="https://ads.example"></iframe><style>p{}</style><em>ok</em>`,
+			want: `<em>ok</em>`,
+		},
+		{
+			name: "text is escaped",
+			in:   `<p>a &lt;b&gt; c</p>`,
+			want: `<p>a &lt;b&gt; c</p>`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := HTML(tc.in, base)
+			if got != tc.want {
+				t.Errorf("HTML(%q)\n got: %s\nwant: %s", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/feed/")
+	if got := ResolveURL("/posts/1", base); got != "https://example.com/posts/1" {
+		t.Errorf("ResolveURL = %s", got)
+	}
+	if got := ResolveURL("https://other.com/x", base); got != "https://other.com/x" {
+		t.Errorf("ResolveURL = %s", got)
+	}
+	if got := ResolveURL("/posts/1", nil); got != "/posts/1" {
+		t.Errorf("ResolveURL = %s", got)
+	}
+}
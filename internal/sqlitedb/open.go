@@ -0,0 +1,20 @@
+package sqlitedb
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the SQLite database file at path, creating it if needed.
+// Foreign keys are enforced so deletes cascade like they do in PostgreSQL,
+// and writers wait for each other instead of failing with SQLITE_BUSY.
+func Open(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Set("_time_format", "sqlite")
+	return sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+}
@@ -0,0 +1,379 @@
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+// Store runs gator's queries against SQLite. sqlc generates separate types
+// for each engine, but ours have the same fields, so Store converts them to
+// and from the database package's types.
+type Store struct {
+	q *Queries
+}
+
+var _ database.Querier = (*Store)(nil)
+
+func NewStore(db DBTX) *Store {
+	return &Store{q: New(utcDB{db: db})}
+}
+
+func (s *Store) AddFeed(ctx context.Context, arg database.AddFeedParams) (database.Feed, error) {
+	row, err := s.q.AddFeed(ctx, AddFeedParams(arg))
+	return database.Feed(row), err
+}
+
+func (s *Store) CountDueFeeds(ctx context.Context, nextFetchAt sql.NullTime) (int64, error) {
+	return s.q.CountDueFeeds(ctx, nextFetchAt)
+}
+
+func (s *Store) CountNewerPosts(ctx context.Context, arg database.CountNewerPostsParams) (int64, error) {
+	return s.q.CountNewerPosts(ctx, CountNewerPostsParams(arg))
+}
+
+func (s *Store) CreateEnclosure(ctx context.Context, arg database.CreateEnclosureParams) (database.Enclosure, error) {
+	row, err := s.q.CreateEnclosure(ctx, CreateEnclosureParams(arg))
+	return database.Enclosure(row), err
+}
+
+func (s *Store) CreateFeedEvent(ctx context.Context, arg database.CreateFeedEventParams) error {
+	return s.q.CreateFeedEvent(ctx, CreateFeedEventParams(arg))
+}
+
+func (s *Store) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	row, err := s.q.CreateFeedFollow(ctx, CreateFeedFollowParams(arg))
+	return database.CreateFeedFollowRow(row), err
+}
+
+func (s *Store) CreateFetchLog(ctx context.Context, arg database.CreateFetchLogParams) error {
+	return s.q.CreateFetchLog(ctx, CreateFetchLogParams(arg))
+}
+
+func (s *Store) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	row, err := s.q.CreatePost(ctx, CreatePostParams(arg))
+	return database.Post(row), err
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	row, err := s.q.CreateUser(ctx, CreateUserParams(arg))
+	return database.User(row), err
+}
+
+func (s *Store) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeleteFeed(ctx, id)
+}
+
+func (s *Store) DeleteFeedHeaders(ctx context.Context, feedID uuid.UUID) error {
+	return s.q.DeleteFeedHeaders(ctx, feedID)
+}
+
+func (s *Store) DeleteFetchLogBefore(ctx context.Context, startedAt time.Time) (int64, error) {
+	return s.q.DeleteFetchLogBefore(ctx, startedAt)
+}
+
+func (s *Store) DeletePost(ctx context.Context, id uuid.UUID) error {
+	return s.q.DeletePost(ctx, id)
+}
+
+func (s *Store) DeleteUserFeed(ctx context.Context, arg database.DeleteUserFeedParams) error {
+	return s.q.DeleteUserFeed(ctx, DeleteUserFeedParams(arg))
+}
+
+func (s *Store) DeleteUsers(ctx context.Context) error {
+	return s.q.DeleteUsers(ctx)
+}
+
+func (s *Store) DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error {
+	return s.q.DeleteWebSubSubscription(ctx, feedID)
+}
+
+func (s *Store) GetEnclosuresForPost(ctx context.Context, postID uuid.UUID) ([]database.Enclosure, error) {
+	rows, err := s.q.GetEnclosuresForPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.Enclosure, len(rows))
+	for i, row := range rows {
+		items[i] = database.Enclosure(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeed(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	row, err := s.q.GetFeed(ctx, id)
+	return database.Feed(row), err
+}
+
+func (s *Store) GetFeedByURL(ctx context.Context, url string) (database.Feed, error) {
+	row, err := s.q.GetFeedByURL(ctx, url)
+	return database.Feed(row), err
+}
+
+func (s *Store) GetFeedEventsForUser(ctx context.Context, arg database.GetFeedEventsForUserParams) ([]database.GetFeedEventsForUserRow, error) {
+	rows, err := s.q.GetFeedEventsForUser(ctx, GetFeedEventsForUserParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.GetFeedEventsForUserRow, len(rows))
+	for i, row := range rows {
+		items[i] = database.GetFeedEventsForUserRow(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	rows, err := s.q.GetFeedFollowsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.GetFeedFollowsForUserRow, len(rows))
+	for i, row := range rows {
+		items[i] = database.GetFeedFollowsForUserRow(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeedHeaders(ctx context.Context, feedID uuid.UUID) ([]database.FeedHeader, error) {
+	rows, err := s.q.GetFeedHeaders(ctx, feedID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.FeedHeader, len(rows))
+	for i, row := range rows {
+		items[i] = database.FeedHeader(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	rows, err := s.q.GetFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.Feed, len(rows))
+	for i, row := range rows {
+		items[i] = database.Feed(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeedsByName(ctx context.Context, lower string) ([]database.Feed, error) {
+	rows, err := s.q.GetFeedsByName(ctx, lower)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.Feed, len(rows))
+	for i, row := range rows {
+		items[i] = database.Feed(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeedsToFetch(ctx context.Context, arg database.GetFeedsToFetchParams) ([]database.Feed, error) {
+	rows, err := s.q.GetFeedsToFetch(ctx, GetFeedsToFetchParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.Feed, len(rows))
+	for i, row := range rows {
+		items[i] = database.Feed(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetFetchLog(ctx context.Context, arg database.GetFetchLogParams) ([]database.GetFetchLogRow, error) {
+	rows, err := s.q.GetFetchLog(ctx, GetFetchLogParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.GetFetchLogRow, len(rows))
+	for i, row := range rows {
+		items[i] = database.GetFetchLogRow(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetPost(ctx context.Context, id uuid.UUID) (database.Post, error) {
+	row, err := s.q.GetPost(ctx, id)
+	return database.Post(row), err
+}
+
+func (s *Store) GetPostByURL(ctx context.Context, url string) (database.Post, error) {
+	row, err := s.q.GetPostByURL(ctx, url)
+	return database.Post(row), err
+}
+
+func (s *Store) GetPostForUser(ctx context.Context, limit int32) ([]database.Post, error) {
+	rows, err := s.q.GetPostForUser(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.Post, len(rows))
+	for i, row := range rows {
+		items[i] = database.Post(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetPosts(ctx context.Context) ([]database.Post, error) {
+	rows, err := s.q.GetPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.Post, len(rows))
+	for i, row := range rows {
+		items[i] = database.Post(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetPrunablePosts(ctx context.Context, arg database.GetPrunablePostsParams) ([]database.GetPrunablePostsRow, error) {
+	rows, err := s.q.GetPrunablePosts(ctx, GetPrunablePostsParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.GetPrunablePostsRow, len(rows))
+	for i, row := range rows {
+		items[i] = database.GetPrunablePostsRow(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetRecentFetchErrors(ctx context.Context, arg database.GetRecentFetchErrorsParams) ([]string, error) {
+	return s.q.GetRecentFetchErrors(ctx, GetRecentFetchErrorsParams(arg))
+}
+
+func (s *Store) GetRecentPostDates(ctx context.Context, arg database.GetRecentPostDatesParams) ([]time.Time, error) {
+	return s.q.GetRecentPostDates(ctx, GetRecentPostDatesParams(arg))
+}
+
+func (s *Store) GetStarredPostsForUser(ctx context.Context, userID uuid.UUID) ([]database.Post, error) {
+	rows, err := s.q.GetStarredPostsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.Post, len(rows))
+	for i, row := range rows {
+		items[i] = database.Post(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, name string) (database.User, error) {
+	row, err := s.q.GetUser(ctx, name)
+	return database.User(row), err
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	row, err := s.q.GetUserByID(ctx, id)
+	return database.User(row), err
+}
+
+func (s *Store) GetUsers(ctx context.Context) ([]database.User, error) {
+	rows, err := s.q.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.User, len(rows))
+	for i, row := range rows {
+		items[i] = database.User(row)
+	}
+	return items, nil
+}
+
+func (s *Store) GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (database.WebsubSubscription, error) {
+	row, err := s.q.GetWebSubSubscription(ctx, feedID)
+	return database.WebsubSubscription(row), err
+}
+
+func (s *Store) GetWebSubSubscriptionsToRenew(ctx context.Context, arg database.GetWebSubSubscriptionsToRenewParams) ([]database.WebsubSubscription, error) {
+	rows, err := s.q.GetWebSubSubscriptionsToRenew(ctx, GetWebSubSubscriptionsToRenewParams(arg))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.WebsubSubscription, len(rows))
+	for i, row := range rows {
+		items[i] = database.WebsubSubscription(row)
+	}
+	return items, nil
+}
+
+func (s *Store) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	return s.q.MarkFeedFetched(ctx, MarkFeedFetchedParams(arg))
+}
+
+func (s *Store) MarkWebSubRequested(ctx context.Context, arg database.MarkWebSubRequestedParams) error {
+	return s.q.MarkWebSubRequested(ctx, MarkWebSubRequestedParams(arg))
+}
+
+func (s *Store) MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) error {
+	return s.q.MoveFeedFollows(ctx, MoveFeedFollowsParams(arg))
+}
+
+func (s *Store) MovePosts(ctx context.Context, arg database.MovePostsParams) error {
+	return s.q.MovePosts(ctx, MovePostsParams(arg))
+}
+
+func (s *Store) RecordFeedRedirect(ctx context.Context, arg database.RecordFeedRedirectParams) error {
+	return s.q.RecordFeedRedirect(ctx, RecordFeedRedirectParams(arg))
+}
+
+func (s *Store) SaveWebSubSubscription(ctx context.Context, arg database.SaveWebSubSubscriptionParams) error {
+	return s.q.SaveWebSubSubscription(ctx, SaveWebSubSubscriptionParams(arg))
+}
+
+func (s *Store) ScheduleFeedFetch(ctx context.Context, arg database.ScheduleFeedFetchParams) error {
+	return s.q.ScheduleFeedFetch(ctx, ScheduleFeedFetchParams(arg))
+}
+
+func (s *Store) SetFeedDisabled(ctx context.Context, arg database.SetFeedDisabledParams) error {
+	return s.q.SetFeedDisabled(ctx, SetFeedDisabledParams(arg))
+}
+
+func (s *Store) SetFeedFullText(ctx context.Context, arg database.SetFeedFullTextParams) error {
+	return s.q.SetFeedFullText(ctx, SetFeedFullTextParams(arg))
+}
+
+func (s *Store) SetFeedHeader(ctx context.Context, arg database.SetFeedHeaderParams) error {
+	return s.q.SetFeedHeader(ctx, SetFeedHeaderParams(arg))
+}
+
+func (s *Store) SetFeedInterval(ctx context.Context, arg database.SetFeedIntervalParams) error {
+	return s.q.SetFeedInterval(ctx, SetFeedIntervalParams(arg))
+}
+
+func (s *Store) SetFeedParsedWithFixes(ctx context.Context, arg database.SetFeedParsedWithFixesParams) error {
+	return s.q.SetFeedParsedWithFixes(ctx, SetFeedParsedWithFixesParams(arg))
+}
+
+func (s *Store) SetFeedRetention(ctx context.Context, arg database.SetFeedRetentionParams) error {
+	return s.q.SetFeedRetention(ctx, SetFeedRetentionParams(arg))
+}
+
+func (s *Store) SetWebSubLease(ctx context.Context, arg database.SetWebSubLeaseParams) error {
+	return s.q.SetWebSubLease(ctx, SetWebSubLeaseParams(arg))
+}
+
+func (s *Store) StarPost(ctx context.Context, arg database.StarPostParams) error {
+	return s.q.StarPost(ctx, StarPostParams(arg))
+}
+
+func (s *Store) UnstarPost(ctx context.Context, arg database.UnstarPostParams) error {
+	return s.q.UnstarPost(ctx, UnstarPostParams(arg))
+}
+
+func (s *Store) UpdateFeedURL(ctx context.Context, arg database.UpdateFeedURLParams) error {
+	return s.q.UpdateFeedURL(ctx, UpdateFeedURLParams(arg))
+}
+
+func (s *Store) UpdatePostContent(ctx context.Context, arg database.UpdatePostContentParams) error {
+	return s.q.UpdatePostContent(ctx, UpdatePostContentParams(arg))
+}
+
+func (s *Store) UpdatePostURL(ctx context.Context, arg database.UpdatePostURLParams) error {
+	return s.q.UpdatePostURL(ctx, UpdatePostURLParams(arg))
+}
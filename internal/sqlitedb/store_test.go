@@ -0,0 +1,246 @@
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/migrate"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrate.SQLite, os.DirFS("../../sql/sqlite/schema"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewStore(db)
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	user, err := store.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "kahya"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := store.AddFeed(ctx, database.AddFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      "Example",
+		Url:       "https://example.com/feed",
+		UserID:    user.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.UserID != user.ID || !feed.CreatedAt.Equal(now) || feed.FetchFullText || feed.RetentionDays.Valid {
+		t.Errorf("feed = %+v", feed)
+	}
+
+	follow, err := store.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if follow.FeedName != "Example" || follow.UserName != "kahya" {
+		t.Errorf("follow = %+v", follow)
+	}
+
+	found, err := store.GetFeedsByName(ctx, "EXAMPLE")
+	if err != nil || len(found) != 1 || found[0].ID != feed.ID {
+		t.Errorf("GetFeedsByName = %+v, %v", found, err)
+	}
+
+	due, err := store.CountDueFeeds(ctx, sql.NullTime{Time: now, Valid: true})
+	if err != nil || due != 1 {
+		t.Errorf("CountDueFeeds = %d, %v", due, err)
+	}
+
+	var posts []database.Post
+	for i := range 3 {
+		post, err := store.CreatePost(ctx, database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       "post",
+			Url:         "https://example.com/" + string(rune('a'+i)),
+			PublishedAt: now.AddDate(0, 0, -10*i),
+			FeedID:      feed.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, post)
+	}
+	err = store.StarPost(ctx, database.StarPostParams{UserID: user.ID, PostID: posts[2].ID, CreatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keeping one post leaves the two older ones, but the oldest is starred
+	prunable, err := store.GetPrunablePosts(ctx, database.GetPrunablePostsParams{FeedID: feed.ID, KeepItems: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prunable) != 1 || prunable[0].ID != posts[1].ID {
+		t.Errorf("GetPrunablePosts by items = %+v", prunable)
+	}
+	prunable, err = store.GetPrunablePosts(ctx, database.GetPrunablePostsParams{
+		FeedID:          feed.ID,
+		PublishedBefore: sql.NullTime{Time: now.AddDate(0, 0, -5), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prunable) != 1 || prunable[0].ID != posts[1].ID {
+		t.Errorf("GetPrunablePosts by age = %+v", prunable)
+	}
+
+	newer, err := store.CountNewerPosts(ctx, database.CountNewerPostsParams{FeedID: feed.ID, PublishedAt: now.AddDate(0, 0, -15)})
+	if err != nil || newer != 2 {
+		t.Errorf("CountNewerPosts = %d, %v", newer, err)
+	}
+
+	err = store.CreateFetchLog(ctx, database.CreateFetchLogParams{ID: uuid.New(), FeedID: feed.ID, StartedAt: now, FinishedAt: now, StatusCode: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, feedID := range []uuid.NullUUID{{}, {UUID: feed.ID, Valid: true}} {
+		entries, err := store.GetFetchLog(ctx, database.GetFetchLogParams{Since: now.Add(-time.Hour), FeedID: feedID})
+		if err != nil || len(entries) != 1 || entries[0].FeedName != "Example" {
+			t.Errorf("GetFetchLog(%v) = %+v, %v", feedID, entries, err)
+		}
+	}
+
+	// Deleting the user takes everything else with it
+	err = store.DeleteUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := store.GetPosts(ctx)
+	if err != nil || len(all) != 0 {
+		t.Errorf("GetPosts after DeleteUsers = %d posts, %v", len(all), err)
+	}
+}
+
+func TestStoreComparesTimesAcrossOffsets(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Now().UTC()
+
+	user, err := store.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "kahya"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := store.AddFeed(ctx, database.AddFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Example", Url: "https://example.com/feed", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plus2 := time.FixedZone("+02:00", 2*60*60)
+	minus3 := time.FixedZone("-03:00", -3*60*60)
+	published := []time.Time{
+		time.Date(2024, 1, 1, 10, 0, 0, 0, plus2),   // 08:00 UTC
+		time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), // 09:00 UTC
+		time.Date(2024, 1, 1, 7, 0, 0, 0, minus3),   // 10:00 UTC
+	}
+	for i, at := range published {
+		_, err := store.CreatePost(ctx, database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       "post",
+			Url:         "https://example.com/" + string(rune('a'+i)),
+			PublishedAt: at,
+			FeedID:      feed.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dates, err := store.GetRecentPostDates(ctx, database.GetRecentPostDatesParams{FeedID: feed.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{published[2], published[1], published[0]}
+	if len(dates) != len(want) {
+		t.Fatalf("dates = %v", dates)
+	}
+	for i := range want {
+		if !dates[i].Equal(want[i]) {
+			t.Errorf("dates = %v, want newest first %v", dates, want)
+			break
+		}
+	}
+
+	// 08:30 UTC, written with an offset that sorts before every stored string
+	newer, err := store.CountNewerPosts(ctx, database.CountNewerPostsParams{
+		FeedID:      feed.ID,
+		PublishedAt: time.Date(2024, 1, 1, 5, 30, 0, 0, minus3),
+	})
+	if err != nil || newer != 2 {
+		t.Errorf("CountNewerPosts = %d, %v, want 2", newer, err)
+	}
+}
+
+func TestStoreDeletesFetchLogBefore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Now().UTC()
+
+	user, err := store.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "kahya"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := store.AddFeed(ctx, database.AddFeedParams{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: "Example", Url: "https://example.com/feed", UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cutoff := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, started := range []time.Time{
+		cutoff.Add(-time.Microsecond),
+		cutoff,
+		// The same instant as the cutoff in another zone
+		cutoff.In(time.FixedZone("+02:00", 2*60*60)),
+		cutoff.Add(time.Second),
+	} {
+		err := store.CreateFetchLog(ctx, database.CreateFetchLogParams{ID: uuid.New(), FeedID: feed.ID, StartedAt: started, FinishedAt: started})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := store.DeleteFetchLogBefore(ctx, cutoff)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteFetchLogBefore = %d, %v", deleted, err)
+	}
+	log, err := store.GetFetchLog(ctx, database.GetFetchLogParams{})
+	if err != nil || len(log) != 3 {
+		t.Fatalf("fetch log = %+v, %v", log, err)
+	}
+	for _, entry := range log {
+		if entry.StartedAt.Before(cutoff) {
+			t.Errorf("fetch started at %v wasn't deleted", entry.StartedAt)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// utcDB converts time arguments to UTC before they reach SQLite. Times are
+// stored as text there, so values with different UTC offsets would otherwise
+// sort and compare as strings rather than as instants.
+type utcDB struct {
+	db DBTX
+}
+
+func (u utcDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return u.db.ExecContext(ctx, query, toUTC(args)...)
+}
+
+func (u utcDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return u.db.PrepareContext(ctx, query)
+}
+
+func (u utcDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return u.db.QueryContext(ctx, query, toUTC(args)...)
+}
+
+func (u utcDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return u.db.QueryRowContext(ctx, query, toUTC(args)...)
+}
+
+func toUTC(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = v.UTC()
+		case sql.NullTime:
+			if v.Valid {
+				v.Time = v.Time.UTC()
+			}
+			converted[i] = v
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
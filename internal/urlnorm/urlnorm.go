@@ -0,0 +1,127 @@
+package urlnorm
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only identify where a click came
+// from and never change the resource. Names ending in * match by prefix.
+var trackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"yclid",
+	"mc_cid",
+	"mc_eid",
+	"_hsenc",
+	"_hsmi",
+	"igshid",
+	"mkt_tok",
+	"oly_anon_id",
+	"oly_enc_id",
+	"vero_id",
+	"wt_mc",
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize returns a canonical form of rawURL: lowercase scheme and host,
+// no default port, no fragment and no tracking parameters. The path and the
+// order of the remaining query parameters are left alone, since servers
+// often treat them as significant. URLs without a scheme are assumed to be
+// https.
+func Normalize(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q: missing host", rawURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port != "" && port != defaultPorts[u.Scheme] {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6 literal
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+
+	if u.RawQuery != "" {
+		var kept []string
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			key, _, _ := strings.Cut(param, "=")
+			if unescaped, err := url.QueryUnescape(key); err == nil {
+				key = unescaped
+			}
+			if param != "" && !isTrackingParam(key) {
+				kept = append(kept, param)
+			}
+		}
+		u.RawQuery = strings.Join(kept, "&")
+	}
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// Key returns an identity for rawURL that ignores everything Normalize does
+// and, in addition, the http/https scheme. Two URLs with the same key refer
+// to the same feed or post.
+func Key(rawURL string) (string, error) {
+	normalized, err := Normalize(rawURL)
+	if err != nil {
+		return "", err
+	}
+	_, rest, _ := strings.Cut(normalized, "://")
+	return rest, nil
+}
+
+// WithOtherScheme returns normalized with its scheme swapped between http and
+// https, for lookups that should match either.
+func WithOtherScheme(normalized string) string {
+	if rest, ok := strings.CutPrefix(normalized, "https://"); ok {
+		return "http://" + rest
+	}
+	if rest, ok := strings.CutPrefix(normalized, "http://"); ok {
+		return "https://" + rest
+	}
+	return normalized
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, param := range trackingParams {
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == param {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package urlnorm
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://x.com/feed", "https://x.com/feed"},
+		{"https://x.com/feed/", "https://x.com/feed/"},
+		{"HTTPS://X.com/feed", "https://x.com/feed"},
+		{"https://X.com:443/feed", "https://x.com/feed"},
+		{"http://x.com:80/feed", "http://x.com/feed"},
+		{"http://x.com:8080/feed", "http://x.com:8080/feed"},
+		{"https://x.com/feed?utm_source=rss&utm_medium=feed", "https://x.com/feed"},
+		{"https://x.com/feed?b=2&fbclid=abc&a=1", "https://x.com/feed?b=2&a=1"},
+		{"https://x.com/feed/?utm_source=rss", "https://x.com/feed/"},
+		{"https://x.com/feed?", "https://x.com/feed"},
+		{"https://x.com/post#comments", "https://x.com/post"},
+		{"https://x.com", "https://x.com/"},
+		{"https://x.com/", "https://x.com/"},
+		{"x.com/feed", "https://x.com/feed"},
+		{"  https://x.com/feed  ", "https://x.com/feed"},
+		{"https://x.com/Case/Path", "https://x.com/Case/Path"},
+		{"http://[::1]:80/feed", "http://[::1]/feed"},
+		{"http://[::1]:8080/feed", "http://[::1]:8080/feed"},
+		{"https://[2001:DB8::1]:8443/rss.xml", "https://[2001:db8::1]:8443/rss.xml"},
+		{"https://[2001:db8::1]/rss.xml", "https://[2001:db8::1]/rss.xml"},
+	}
+
+	for _, tc := range tests {
+		got, err := Normalize(tc.in)
+		if err != nil {
+			t.Errorf("Normalize(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	for _, in := range []string{"https://", "http://%zz"} {
+		if _, err := Normalize(in); err == nil {
+			t.Errorf("Normalize(%q): expected error", in)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	urls := []string{
+		"http://x.com/feed",
+		"https://x.com:443/feed#top",
+		"https://X.com/feed?utm_source=newsletter",
+	}
+	want, err := Key(urls[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range urls[1:] {
+		got, err := Key(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Key(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
+
+func TestWithOtherScheme(t *testing.T) {
+	if got := WithOtherScheme("https://x.com/feed"); got != "http://x.com/feed" {
+		t.Errorf("got %q", got)
+	}
+	if got := WithOtherScheme("http://x.com/feed"); got != "https://x.com/feed" {
+		t.Errorf("got %q", got)
+	}
+}
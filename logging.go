@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/config"
+)
+
+// newLogger builds the logger for the log_level and log_format settings,
+// defaulting to info and text.
+func newLogger(cfg *config.Config, w io.Writer) *slog.Logger {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(cfg.LogLevel))
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(cfg.LogFormat, "json") {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// feedLog is the logger for events about a feed.
+func feedLog(feedID uuid.UUID, url string) *slog.Logger {
+	return slog.With("feed_id", feedID, "url", url)
+}
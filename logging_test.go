@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/config"
+)
+
+func TestNewLogger(t *testing.T) {
+	var b strings.Builder
+	logger := newLogger(&config.Config{LogLevel: "warn", LogFormat: "json"}, &b)
+	feedID := uuid.New()
+
+	logger.Info("fetched feed", "feed_id", feedID)
+	logger.Warn("fetch failed", "feed_id", feedID, "url", "https://example.com/feed", "error", "timeout")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want only the warning:\n%s", len(lines), b.String())
+	}
+	var entry map[string]any
+	err := json.Unmarshal([]byte(lines[0]), &entry)
+	if err != nil {
+		t.Fatalf("not JSON: %v", err)
+	}
+	if entry["msg"] != "fetch failed" || entry["feed_id"] != feedID.String() || entry["url"] != "https://example.com/feed" || entry["error"] != "timeout" {
+		t.Errorf("entry = %v", entry)
+	}
+
+	b.Reset()
+	logger = newLogger(&config.Config{LogLevel: "nonsense"}, &b)
+	logger.Debug("hidden")
+	logger.Info("shown")
+	if strings.Contains(b.String(), "hidden") || !strings.Contains(b.String(), "msg=shown") {
+		t.Errorf("default level should be info as text, got %q", b.String())
+	}
+}
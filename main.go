@@ -3,17 +3,24 @@ package main
 import _ "github.com/lib/pq"
 
 import (
-	"database/sql"
 	"log"
+	"log/slog"
 	"os"
 	
 	"github.com/mortalglitch/gator/internal/config"
 	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/migrate"
 )
 
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db       database.Querier
+	migrator *migrate.Migrator
+	cfg      *config.Config
+	fetcher  *feedFetcher
+	limiter  *hostLimiter
+	// websub is only set under gator serve, which can receive callbacks
+	websub  *webSubscriber
+	metrics *aggMetrics
 }
 
 func main() {
@@ -22,32 +29,29 @@ func main() {
 		log.Fatalf("error reading config: %v", err)
 	}
 
-	db, err := sql.Open("postgres", cfg.DBURL)
+	slog.SetDefault(newLogger(&cfg, os.Stderr))
+
+	db, dbQueries, migrator, err := openDB(cfg.DBURL)
 	if err != nil {
 		log.Fatalf("error connecting to db: %v", err)
 	}
 	defer db.Close()
-	dbQueries := database.New(db)
 
-	programState := &state{
-		db:  dbQueries,
-		cfg: &cfg,
+	fetcher, err := newFeedFetcher(&cfg)
+	if err != nil {
+		log.Fatalf("error setting up http client: %v", err)
 	}
 
-	cmds := commands{
-		registeredCommands: make(map[string]func(*state, command) error),
+	programState := &state{
+		db:       dbQueries,
+		migrator: migrator,
+		cfg:      &cfg,
+		fetcher:  fetcher,
+		limiter:  newHostLimiter(cfg.MaxHostConnections(), cfg.HostDelay()),
+		metrics:  newAggMetrics(),
 	}
-	cmds.register("login", handlerLogin)
-	cmds.register("register", handlerRegister)
-	cmds.register("reset", handlerReset)
-	cmds.register("users", handlerListUsers)
-	cmds.register("agg", handlerAgg)
-	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	cmds.register("feeds", handlerListFeeds)
-	cmds.register("follow", middlewareLoggedIn(handlerFollow))
-	cmds.register("following", middlewareLoggedIn(handlerFollowing))
-	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
-	cmds.register("browse", handlerBrowse)
+
+	cmds := newCommands()
 
 	if len(os.Args) < 2 {
 		log.Fatal("Usage: cli <command> [args...]")
@@ -56,6 +60,13 @@ func main() {
 	cmdName := os.Args[1]
 	cmdArgs := os.Args[2:]
 
+	if cmdName != "migrate" {
+		err = checkSchema(programState)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	err = cmds.run(programState, command{Name: cmdName, Args: cmdArgs})
 	if err != nil {
 		log.Fatal(err)
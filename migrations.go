@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed sql/schema/*.sql
+var postgresMigrations embed.FS
+
+//go:embed sql/sqlite/schema/*.sql
+var sqliteMigrations embed.FS
+
+func migrationsDir(fsys embed.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+func handlerMigrate(s *state, cmd command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %v <up|down|status>", cmd.Name)
+	}
+
+	switch cmd.Args[0] {
+	case "up":
+		applied, err := s.migrator.Up(context.Background())
+		for _, migration := range applied {
+			fmt.Printf("Applied %s\n", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		migration, ok, err := s.migrator.Down(context.Background())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("No migrations to roll back")
+			return nil
+		}
+		fmt.Printf("Rolled back %s\n", migration.Name)
+	case "status":
+		statuses, err := s.migrator.Status(context.Background())
+		if err != nil {
+			return fmt.Errorf("couldn't read migration status: %w", err)
+		}
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.Applied {
+				appliedAt = "Applied"
+				if status.AppliedAt.Valid {
+					appliedAt = status.AppliedAt.Time.Format("2006-01-02 15:04:05")
+				}
+			}
+			fmt.Printf("%-20s %s\n", appliedAt, status.Name)
+		}
+	default:
+		return fmt.Errorf("usage: %v <up|down|status>", cmd.Name)
+	}
+	return nil
+}
+
+// checkSchema refuses to run commands against a database that is missing
+// migrations, which would fail in confusing ways.
+func checkSchema(s *state) error {
+	pending, err := s.migrator.Pending(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't check the database schema: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("the database schema is out of date (%d migrations pending, starting with %s), run: gator migrate up", len(pending), pending[0].Name)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/config"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+// retentionPolicy says which posts of a feed are kept: those published in
+// the last Days days and the newest Items. 0 means no limit.
+type retentionPolicy struct {
+	Days  int
+	Items int
+}
+
+// feedRetention is the feed's own policy, with the config filling in
+// whatever the feed doesn't set.
+func feedRetention(cfg *config.Config, feed database.Feed) retentionPolicy {
+	policy := retentionPolicy{Days: cfg.RetentionDays, Items: cfg.RetentionItems}
+	if feed.RetentionDays.Valid {
+		policy.Days = int(feed.RetentionDays.Int32)
+	}
+	if feed.RetentionItems.Valid {
+		policy.Items = int(feed.RetentionItems.Int32)
+	}
+	return policy
+}
+
+func (p retentionPolicy) keepsAll() bool {
+	return p.Days <= 0 && p.Items <= 0
+}
+
+func (p retentionPolicy) String() string {
+	switch {
+	case p.keepsAll():
+		return "keep everything"
+	case p.Items <= 0:
+		return fmt.Sprintf("keep %d days", p.Days)
+	case p.Days <= 0:
+		return fmt.Sprintf("keep %d items", p.Items)
+	}
+	return fmt.Sprintf("keep %d days, at most %d items", p.Days, p.Items)
+}
+
+func (p retentionPolicy) params(feed database.Feed, now time.Time) database.GetPrunablePostsParams {
+	params := database.GetPrunablePostsParams{FeedID: feed.ID}
+	if p.Days > 0 {
+		params.PublishedBefore = sql.NullTime{Time: now.AddDate(0, 0, -p.Days), Valid: true}
+	}
+	if p.Items > 0 {
+		params.KeepItems = int32(p.Items)
+	}
+	return params
+}
+
+// keepsNewPost reports whether a post published at the given time would
+// survive the next prune of feed. Items the policy drops aren't saved, so
+// pruned posts don't come back each time the feed still lists them.
+func (p retentionPolicy) keepsNewPost(s *state, feed database.Feed, published, now time.Time) (bool, error) {
+	if p.Days > 0 && published.Before(now.AddDate(0, 0, -p.Days)) {
+		return false, nil
+	}
+	if p.Items > 0 {
+		newer, err := s.db.CountNewerPosts(context.Background(), database.CountNewerPostsParams{
+			FeedID:      feed.ID,
+			PublishedAt: published,
+		})
+		if err != nil {
+			return false, fmt.Errorf("couldn't count newer posts: %w", err)
+		}
+		return newer < int64(p.Items), nil
+	}
+	return true, nil
+}
+
+// pruneFeed returns the posts of feed its retention policy doesn't keep and,
+// unless dryRun is set, deletes them. Starred posts are never returned.
+func pruneFeed(s *state, feed database.Feed, dryRun bool) ([]database.GetPrunablePostsRow, error) {
+	policy := feedRetention(s.cfg, feed)
+	if policy.keepsAll() {
+		return nil, nil
+	}
+
+	posts, err := s.db.GetPrunablePosts(context.Background(), policy.params(feed, time.Now().UTC()))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find posts to prune: %w", err)
+	}
+	if dryRun {
+		return posts, nil
+	}
+	for i, post := range posts {
+		err := s.db.DeletePost(context.Background(), post.ID)
+		if err != nil {
+			return posts[:i], fmt.Errorf("couldn't delete post %v: %w", post.ID, err)
+		}
+	}
+	return posts, nil
+}
+
+// autoPrune prunes every feed when prune_after_agg is set.
+func autoPrune(s *state) {
+	if !s.cfg.PruneAfterAgg {
+		return
+	}
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		slog.Error("couldn't list feeds to prune", "error", err)
+		return
+	}
+	for _, feed := range feeds {
+		posts, err := pruneFeed(s, feed, false)
+		if err != nil {
+			feedLog(feed.ID, feed.Url).Error("couldn't prune feed", "error", err)
+		}
+		if len(posts) > 0 {
+			feedLog(feed.ID, feed.Url).Info("pruned posts", "posts", len(posts))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/config"
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/feedtest"
+)
+
+func TestFeedRetention(t *testing.T) {
+	cfg := &config.Config{RetentionDays: 30, RetentionItems: 100}
+
+	tests := []struct {
+		name string
+		feed database.Feed
+		want retentionPolicy
+	}{
+		{"config default", database.Feed{}, retentionPolicy{Days: 30, Items: 100}},
+		{"days override", database.Feed{RetentionDays: sql.NullInt32{Int32: 7, Valid: true}}, retentionPolicy{Days: 7, Items: 100}},
+		{"no limits", database.Feed{
+			RetentionDays:  sql.NullInt32{Valid: true},
+			RetentionItems: sql.NullInt32{Valid: true},
+		}, retentionPolicy{}},
+	}
+	for _, tt := range tests {
+		got := feedRetention(cfg, tt.feed)
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+	if !feedRetention(&config.Config{}, database.Feed{}).keepsAll() {
+		t.Error("an empty config should keep everything")
+	}
+}
+
+func TestRetentionParams(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	params := retentionPolicy{Days: 30}.params(database.Feed{}, now)
+	want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if !params.PublishedBefore.Valid || !params.PublishedBefore.Time.Equal(want) || params.KeepItems != 0 {
+		t.Errorf("days only: got %+v", params)
+	}
+
+	params = retentionPolicy{Items: 50}.params(database.Feed{}, now)
+	if params.PublishedBefore.Valid || params.KeepItems != 50 {
+		t.Errorf("items only: got %+v", params)
+	}
+}
+
+func TestParseRetentionLimit(t *testing.T) {
+	if n, err := parseRetentionLimit("default"); err != nil || n.Valid {
+		t.Errorf("default: got %+v, %v", n, err)
+	}
+	if n, err := parseRetentionLimit("0"); err != nil || !n.Valid || n.Int32 != 0 {
+		t.Errorf("0: got %+v, %v", n, err)
+	}
+	if _, err := parseRetentionLimit("-1"); err == nil {
+		t.Error("-1: no error")
+	}
+}
+
+func TestPrunedPostsStayPruned(t *testing.T) {
+	s := newTestStateWithConfig(t, &config.Config{HostRequestDelay: "0s", RetentionItems: 2, PruneAfterAgg: true})
+	server := feedtest.NewServer(t)
+	day := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var items []feedtest.Item
+	for i, title := range []string{"A", "B", "C", "D"} {
+		items = append(items, feedtest.Item{Title: title, Link: "https://example.com/" + title, Published: day.AddDate(0, 0, i)})
+	}
+	feed := addTestFeed(t, s, "Feed", server.Script("/feed.xml", feedtest.OK(feedtest.RSS("Feed", items...))))
+
+	aggregate(t, s)
+	aggregate(t, s)
+	titles := postTitles(t, s, feed.ID)
+	slices.Sort(titles)
+	if strings.Join(titles, ",") != "C,D" {
+		t.Errorf("posts = %q, want the newest two", titles)
+	}
+	if run := lastFetch(t, s, feed.ID); run.ItemsNew != 0 {
+		t.Errorf("pruned posts were saved again: %+v", run)
+	}
+}
+
+func TestPostsOlderThanRetentionArentSaved(t *testing.T) {
+	s := newTestStateWithConfig(t, &config.Config{HostRequestDelay: "0s", RetentionDays: 30})
+	server := feedtest.NewServer(t)
+	feed := addTestFeed(t, s, "Feed", server.Script("/feed.xml", feedtest.OK(feedtest.RSS("Feed",
+		feedtest.Item{Title: "Recent", Link: "https://example.com/recent", Published: time.Now().AddDate(0, 0, -1)},
+		feedtest.Item{Title: "Old", Link: "https://example.com/old", Published: time.Now().AddDate(0, 0, -60)},
+	))))
+
+	aggregate(t, s)
+	if titles := postTitles(t, s, feed.ID); len(titles) != 1 || titles[0] != "Recent" {
+		t.Errorf("posts = %q", titles)
+	}
+}
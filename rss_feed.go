@@ -1,60 +1,127 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
-	"io"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/readability"
+	"github.com/mortalglitch/gator/internal/sanitize"
+	"github.com/mortalglitch/gator/internal/urlnorm"
 	"github.com/google/uuid"
 )
 
 type RSSFeed struct {
+	// ParsedWithFixes is set when the document was malformed and only
+	// parsed in lenient mode.
+	ParsedWithFixes bool `xml:"-"`
+	// Hub and Self are the WebSub hub the feed advertises and the topic URL
+	// to subscribe to it with.
+	Hub  string `xml:"-"`
+	Self string `xml:"-"`
+
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title       string        `xml:"title"`
+		Link        string        `xml:"-"`
+		Links       []channelLink `xml:"link"`
+		Description string        `xml:"description"`
+		Item        []RSSItem     `xml:"item"`
+
+		TTL             string   `xml:"ttl"`
+		SkipHours       []int    `xml:"skipHours>hour"`
+		SkipDays        []string `xml:"skipDays>day"`
+		UpdatePeriod    string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
 	} `xml:"channel"`
 }
 
+// channelLink is either the RSS <link> of a channel or an <atom:link> in
+// it, which a plain "link" tag can't tell apart.
+type channelLink struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+	Href    string `xml:"href,attr"`
+	Rel     string `xml:"rel,attr"`
+}
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
 type RSSItem struct {
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+
+	Enclosures     []RSSEnclosure `xml:"enclosure"`
+	MediaContent   []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroups    []MediaGroup   `xml:"http://search.yahoo.com/mrss/ group"`
+	ITunesDuration string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
 }
 
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	httpClient := http.Client{
-		Timeout: 10 * time.Second,
-	}
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
-	if err != nil {
-		return nil, err
+// parseFeed decodes an RSS 2.0 or Atom document into an RSSFeed. Documents
+// that aren't well-formed XML are given a second chance in lenient mode.
+func parseFeed(dat []byte) (*RSSFeed, error) {
+	rssFeed, err := decodeFeed(dat, true)
+	if err == nil {
+		return rssFeed, nil
 	}
 
-	req.Header.Set("User-Agent", "gator")
-	resp, err := httpClient.Do(req)
-	if err != nil {
+	rssFeed, lenientErr := decodeFeed(cleanXML(dat), false)
+	if lenientErr != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	rssFeed.ParsedWithFixes = true
+	return rssFeed, nil
+}
 
-	dat, err := io.ReadAll(resp.Body)
+func decodeFeed(dat []byte, strict bool) (*RSSFeed, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	err := unmarshalXML(dat, &root, strict)
 	if err != nil {
 		return nil, err
 	}
 
 	var rssFeed RSSFeed
-	err = xml.Unmarshal(dat, &rssFeed)
-	if err != nil {
-		return nil, err
+	switch root.XMLName.Local {
+	case "feed":
+		var atomFeed AtomFeed
+		err = unmarshalXML(dat, &atomFeed, strict)
+		if err != nil {
+			return nil, err
+		}
+		rssFeed = *atomFeed.toRSS()
+	case "rss":
+		err = unmarshalXML(dat, &rssFeed, strict)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range rssFeed.Channel.Links {
+			switch {
+			case link.XMLName.Space == "" && rssFeed.Channel.Link == "":
+				rssFeed.Channel.Link = strings.TrimSpace(link.Text)
+			case link.XMLName.Space == atomNamespace && link.Rel == "hub" && rssFeed.Hub == "":
+				rssFeed.Hub = link.Href
+			case link.XMLName.Space == atomNamespace && link.Rel == "self" && rssFeed.Self == "":
+				rssFeed.Self = link.Href
+			}
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: root element <%s>", root.XMLName.Local)
 	}
 
 	rssFeed.Channel.Title = html.UnescapeString(rssFeed.Channel.Title)
@@ -68,56 +135,398 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	return &rssFeed, nil
 }
 
+// lenientAutoClose is xml.HTMLAutoClose without <link>, which is a void
+// element in HTML but holds the item's URL in RSS.
+var lenientAutoClose = slices.DeleteFunc(slices.Clone(xml.HTMLAutoClose), func(name string) bool {
+	return name == "link"
+})
+
+// unmarshalXML is xml.Unmarshal with support for non-UTF-8 encodings. When
+// strict is false, HTML entities such as &nbsp; are understood and stray
+// ampersands and unclosed tags are tolerated.
+func unmarshalXML(dat []byte, v any, strict bool) error {
+	decoder := xml.NewDecoder(bytes.NewReader(dat))
+	decoder.CharsetReader = xmlCharsetReader
+	if !strict {
+		decoder.Strict = false
+		decoder.AutoClose = lenientAutoClose
+		decoder.Entity = xml.HTMLEntity
+	}
+	return decoder.Decode(v)
+}
+
+// cleanXML removes what no XML parser accepts: a leading byte order mark,
+// anything before the first tag and control characters.
+func cleanXML(dat []byte) []byte {
+	dat = bytes.TrimPrefix(dat, []byte("\xef\xbb\xbf"))
+	if i := bytes.IndexByte(dat, '<'); i > 0 {
+		dat = dat[i:]
+	}
+	return bytes.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, dat)
+}
+
+// scrapeFeeds fetches the feeds that are due, several at a time. Failed
+// fetches are logged; the error is for failing to list the feeds.
 func scrapeFeeds(s *state) error{
-	feed, err := s.db.GetNextFeedToFetch(context.Background())
+	feeds, err := dueFeeds(s)
 	if err != nil {
 		return err
 	}
-	
-	fmt.Printf("Checking: %s\n", feed.ID)
+	scrapeBatch(s, feeds)
+	trimFetchLog(s)
+	autoPrune(s)
+	return nil
+}
+
+// scrapeAllDueFeeds fetches batch after batch until no feed is due, each feed
+// at most once, and returns how many fetches failed.
+func scrapeAllDueFeeds(s *state) (int, error) {
+	seen := map[uuid.UUID]bool{}
+	failed := 0
+	for {
+		feeds, err := dueFeeds(s)
+		if err != nil {
+			return failed, err
+		}
+		var batch []database.Feed
+		for _, feed := range feeds {
+			if !seen[feed.ID] {
+				seen[feed.ID] = true
+				batch = append(batch, feed)
+			}
+		}
+		if len(batch) == 0 {
+			trimFetchLog(s)
+			autoPrune(s)
+			return failed, nil
+		}
+		failed += scrapeBatch(s, batch)
+	}
+}
+
+func dueFeeds(s *state) ([]database.Feed, error) {
+	s.metrics.observeQueue(s)
+	feeds, err := s.db.GetFeedsToFetch(context.Background(), database.GetFeedsToFetchParams{
+		NextFetchAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		Limit:       int32(s.cfg.MaxConcurrentFetches()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list due feeds: %w", err)
+	}
+	return feeds, nil
+}
+
+// scrapeBatch fetches feeds concurrently, waits for all of them and returns
+// how many failed.
+func scrapeBatch(s *state, feeds []database.Feed) int {
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for _, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !scrapeFeed(s, feed) {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return int(failed.Load())
+}
+
+// scrapeFeed fetches feed and reports whether it succeeded. A feed deferred
+// because its host asked to be left alone hasn't failed.
+func scrapeFeed(s *state, feed database.Feed) bool {
+	log := feedLog(feed.ID, feed.Url)
+	host := feedHost(feed.Url)
+	release, err := s.limiter.acquire(context.Background(), host)
+	var deferred *hostDeferredError
+	if errors.As(err, &deferred) {
+		log.Debug("host asked to be left alone, deferring feed", "until", deferred.Until)
+		deferFeed(s, feed, deferred.Until)
+		return true
+	}
+	if err != nil {
+		log.Error("couldn't wait for host", "error", err)
+		return false
+	}
+	defer release()
+
+	run := fetchRun{FeedID: feed.ID, StartedAt: time.Now().UTC()}
+	err = refreshFeed(s, feed, host, &run)
+	recordFetch(s, run, err)
+	s.metrics.observeFetch(feed, host, run, err)
+	if err != nil {
+		log.Warn("fetch failed", "status", run.StatusCode, "error", err)
+		return false
+	}
+	log.Info("fetched feed",
+		"status", run.StatusCode,
+		"bytes", run.Bytes,
+		"items", run.Items.Seen,
+		"new", run.Items.New,
+		"updated", run.Items.Updated,
+		"duration", time.Since(run.StartedAt),
+	)
+	return true
+}
+
+// refreshFeed fetches feed and saves its items, noting what happened in run.
+func refreshFeed(s *state, feed database.Feed, host string, run *fetchRun) error {
+	feedLog(feed.ID, feed.Url).Debug("fetching feed")
 	// Mark Fetched
-	markComplete := s.db.MarkFeedFetched(context.Background(), database.MarkFeedFetchedParams{
-		LastFetchedAt: sql.NullTime{time.Now().UTC(), true},
+	err := s.db.MarkFeedFetched(context.Background(), database.MarkFeedFetchedParams{
+		LastFetchedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
 		ID:      feed.ID,
 	})
-	if markComplete != nil {
+	if err != nil {
 		return fmt.Errorf("couldn't mark feed as fetched: %w", err)
 	}
 
-	
-
-	rssFeed, err := fetchFeed(context.Background(), feed.Url)
+	header, err := feedHeaders(s, feed.ID)
 	if err != nil {
 		return err
 	}
+	response, err := s.fetcher.fetchFeedResponse(context.Background(), feed.Url, header)
+	if err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			run.StatusCode = statusErr.StatusCode
+			switch statusErr.StatusCode {
+			case http.StatusGone:
+				disableGoneFeed(s, feed)
+			case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+				// Leave the whole host alone for as long as it asked
+				until := retryAfter(statusErr.Header, time.Now())
+				s.limiter.deferHost(host, until)
+				deferFeed(s, feed, until)
+				return err
+			}
+		}
+		scheduleFailedFeed(s, feed)
+		return err
+	}
+	run.StatusCode = response.StatusCode
+	run.Bytes = response.Bytes
+	trackRedirect(s, feed, response.PermanentURL)
+
+	rssFeed := response.Feed
+
+	run.Items, err = ingestFeed(s, feed, rssFeed)
+	hints := feedScheduleHints(rssFeed, response.Header)
+	if hasWebSubLease(s, feed.ID) {
+		// The hub pushes new items, polling is only a fallback
+		hints.MinInterval = max(hints.MinInterval, webSubPollInterval)
+	}
+	scheduleFeed(s, feed, hints)
+	return err
+}
 
-	fmt.Printf("Channel Result: %v\n", rssFeed.Channel.Title)
+// ingestFeed stores a fetched or pushed copy of feed.
+func ingestFeed(s *state, feed database.Feed, rssFeed *RSSFeed) (feedItemStats, error) {
+	recordParseFixes(s, feed, rssFeed)
+	subscribeWebSub(s, feed, rssFeed)
+	return saveFeedItems(s, feed, rssFeed)
+}
+
+// feedItemStats counts what saving a feed's items did.
+type feedItemStats struct {
+	Seen    int
+	New     int
+	Updated int
+}
+
+// saveFeedItems stores the items of rssFeed as posts of feed. Posts that
+// already exist are updated when their title or text changed.
+func saveFeedItems(s *state, feed database.Feed, rssFeed *RSSFeed) (feedItemStats, error) {
+	var stats feedItemStats
+	log := feedLog(feed.ID, feed.Url)
+	feedBase := feedBaseURL(feed.Url, rssFeed.Channel.Link)
+	policy := feedRetention(s.cfg, feed)
+	now := time.Now().UTC()
+	var header http.Header
+	if feed.FetchFullText {
+		var err error
+		header, err = feedHeaders(s, feed.ID)
+		if err != nil {
+			return stats, err
+		}
+	}
 	for _, item := range rssFeed.Channel.Item {
-		fmt.Printf("* %v\n", item.Title)
+		stats.Seen++
 		// Add post to DB
+		// Relative links inside an item are relative to the item itself,
+		// and the item link is relative to the feed.
+		link := sanitize.ResolveURL(item.Link, feedBase)
+		if normalized, err := urlnorm.Normalize(link); err == nil {
+			link = normalized
+		}
+		itemBase := feedBase
+		if parsed, err := url.Parse(link); err == nil && parsed.IsAbs() {
+			itemBase = parsed
+		}
+
+		if existing, err := s.db.GetPostByURL(context.Background(), link); err == nil && link != "" {
+			if existing.FeedID == feed.ID && updatePost(s, feed, existing, item, itemBase) {
+				log.Debug("updated post", "post_url", link, "title", item.Title)
+				stats.Updated++
+			}
+			continue
+		}
+
+		// One item with a date we can't read shouldn't cost the feed
+		// the rest of its items
 		publishTime, err := ParseFlexibleTime(item.PubDate)
 		if err != nil {
-			return err
+			log.Warn("skipped post with an invalid date", "post_url", link, "title", item.Title, "error", err)
+			continue
+		}
+
+		keep, err := policy.keepsNewPost(s, feed, publishTime, now)
+		if err != nil {
+			return stats, err
+		}
+		if !keep {
+			log.Debug("skipped post outside the retention policy", "post_url", link, "title", item.Title)
+			continue
+		}
+
+		content := item.Content
+		if feed.FetchFullText && content == "" && link != "" {
+			content = fetchFullText(s, feed, header, link)
 		}
 
-		newPost, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
+		post, err := s.db.CreatePost(context.Background(), database.CreatePostParams{
 			ID:          uuid.New(),
 			CreatedAt:   time.Now().UTC(),
 			UpdatedAt:   time.Now().UTC(),
 			Title:       item.Title,
-			Url:         item.Link,
-			Description: item.Description,
+			Url:         link,
+			Description: sanitize.HTML(item.Description, itemBase),
 			PublishedAt: publishTime,
 			FeedID:      feed.ID,
+			Content:     sanitize.HTML(content, itemBase),
 		})	
 		if err != nil {
-			fmt.Errorf("Error occured when creating post: %s%v\n", newPost.Title, err)
+			log.Error("couldn't create post", "post_url", link, "title", item.Title, "error", err)
+			continue
+		}
+		log.Debug("saved post", "post_url", link, "title", item.Title)
+		stats.New++
+
+		for _, enclosure := range item.enclosures() {
+			_, err := s.db.CreateEnclosure(context.Background(), database.CreateEnclosureParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(),
+				PostID:    post.ID,
+				Url:       sanitize.ResolveURL(enclosure.URL, itemBase),
+				MimeType:  enclosure.Type,
+				Length:    enclosure.Length,
+				Duration:  int32(enclosure.Duration),
+			})
+			if err != nil {
+				log.Error("couldn't save enclosure", "post_url", link, "enclosure_url", enclosure.URL, "error", err)
+			}
 		}
 	}
 	
+	return stats, nil
+}
+
+// updatePost refreshes a stored post from the feed's current copy of the
+// item, reporting whether anything changed. Extracted full text is kept
+// when the feed still only has a summary.
+func updatePost(s *state, feed database.Feed, post database.Post, item RSSItem, itemBase *url.URL) bool {
+	description := sanitize.HTML(item.Description, itemBase)
+	content := sanitize.HTML(item.Content, itemBase)
+	if feed.FetchFullText && item.Content == "" {
+		content = post.Content
+	}
+	if post.Title == item.Title && post.Description == description && post.Content == content {
+		return false
+	}
+
+	err := s.db.UpdatePostContent(context.Background(), database.UpdatePostContentParams{
+		Title:       item.Title,
+		Description: description,
+		Content:     content,
+		UpdatedAt:   time.Now().UTC(),
+		ID:          post.ID,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't update post", "post_url", post.Url, "title", item.Title, "error", err)
+		return false
+	}
+	return true
+}
+
+// recordParseFixes remembers whether the feed currently needs lenient
+// parsing, so broken feeds can be pointed out to their owners.
+func recordParseFixes(s *state, feed database.Feed, rssFeed *RSSFeed) {
+	if feed.ParsedWithFixes == rssFeed.ParsedWithFixes {
+		return
+	}
+	err := s.db.SetFeedParsedWithFixes(context.Background(), database.SetFeedParsedWithFixesParams{
+		ParsedWithFixes: rssFeed.ParsedWithFixes,
+		ID:              feed.ID,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't record parse fixes", "error", err)
+	}
+}
+
+// feedBaseURL returns the URL that relative links in a feed resolve against:
+// the channel's own link when it is absolute, the feed URL otherwise.
+func feedBaseURL(feedURL, channelLink string) *url.URL {
+	if link, err := url.Parse(channelLink); err == nil && link.IsAbs() {
+		return link
+	}
+	if base, err := url.Parse(feedURL); err == nil && base.IsAbs() {
+		return base
+	}
 	return nil
 }
 
+// fetchFullText extracts the article body from the linked page for feeds that
+// only publish a summary. Requests keep to the host limiter's delay and stop
+// while the host asks to be left alone. The feed's stored headers are sent
+// to pages on the feed's own host only. Failures are reported and leave the
+// content empty.
+func fetchFullText(s *state, feed database.Feed, header http.Header, link string) string {
+	log := feedLog(feed.ID, feed.Url)
+	host := feedHost(link)
+	err := s.limiter.pace(context.Background(), host)
+	if err != nil {
+		log.Warn("couldn't extract full text", "post_url", link, "error", err)
+		return ""
+	}
+
+	if !sameHost(link, feed.Url) {
+		header = nil
+	}
+	page, err := s.fetcher.fetchPage(context.Background(), link, header)
+	if err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode == http.StatusServiceUnavailable) {
+			s.limiter.deferHost(host, retryAfter(statusErr.Header, time.Now()))
+		}
+		log.Warn("couldn't extract full text", "post_url", link, "error", err)
+		return ""
+	}
+	content, err := readability.Extract(bytes.NewReader(page))
+	if err != nil {
+		log.Warn("couldn't extract full text", "post_url", link, "error", err)
+		return ""
+	}
+	return content
+}
+
 var commonLayouts = []string{
 	time.RFC1123Z,
 	time.RFC1123,
@@ -135,7 +544,7 @@ func ParseFlexibleTime(dateString string) (time.Time, error) {
 	for _, layout := range commonLayouts {
 		t, err := time.Parse(layout, trimmedDateString)
 		if err == nil {
-			return t, nil
+			return t.UTC(), nil
 		}
 	}
 	return time.Time{}, fmt.Errorf("failed to parse date string '%s' using %d layouts", dateString, len(commonLayouts))
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseFeedLenient(t *testing.T) {
+	tests := []struct {
+		name      string
+		feed      string
+		title     string
+		link      string
+		itemTitle string
+		itemLink  string
+		fixed     bool
+	}{
+		{
+			name:      "well formed",
+			feed:      `<rss><channel><title>News</title><item><title>One &amp; two</title></item></channel></rss>`,
+			title:     "News",
+			itemTitle: "One & two",
+		},
+		{
+			name:      "unescaped ampersand",
+			feed:      `<rss><channel><title>Tom & Jerry</title><item><title>Cats & mice</title></item></channel></rss>`,
+			title:     "Tom & Jerry",
+			itemTitle: "Cats & mice",
+			fixed:     true,
+		},
+		{
+			name:      "html entity",
+			feed:      `<rss><channel><title>Caf&eacute;</title><item><title>A&nbsp;B</title></item></channel></rss>`,
+			title:     "Café",
+			itemTitle: "A B",
+			fixed:     true,
+		},
+		{
+			name:      "control characters",
+			feed:      "<rss><channel><title>Bell\x07</title><item><title>Null\x00 byte</title></item></channel></rss>",
+			title:     "Bell",
+			itemTitle: "Null byte",
+			fixed:     true,
+		},
+		{
+			name:      "byte order mark with broken markup",
+			feed:      "\xef\xbb\xbf\n  <?xml version=\"1.0\"?><rss><channel><title>BOM</title><item><title>x & y</title></item></channel></rss>",
+			title:     "BOM",
+			itemTitle: "x & y",
+			fixed:     true,
+		},
+		{
+			name:      "links with broken markup",
+			feed:      `<rss><channel><title>Links</title><link>https://example.com/</link><item><title>a & b</title><link>https://example.com/a</link></item></channel></rss>`,
+			title:     "Links",
+			link:      "https://example.com/",
+			itemTitle: "a & b",
+			itemLink:  "https://example.com/a",
+			fixed:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rssFeed, err := parseFeed([]byte(tc.feed))
+			if err != nil {
+				t.Fatalf("parseFeed: %v", err)
+			}
+			if rssFeed.Channel.Title != tc.title {
+				t.Errorf("title = %q, want %q", rssFeed.Channel.Title, tc.title)
+			}
+			if rssFeed.Channel.Link != tc.link {
+				t.Errorf("link = %q, want %q", rssFeed.Channel.Link, tc.link)
+			}
+			if len(rssFeed.Channel.Item) != 1 || rssFeed.Channel.Item[0].Title != tc.itemTitle || rssFeed.Channel.Item[0].Link != tc.itemLink {
+				t.Errorf("items = %+v, want one titled %q linking to %q", rssFeed.Channel.Item, tc.itemTitle, tc.itemLink)
+			}
+			if rssFeed.ParsedWithFixes != tc.fixed {
+				t.Errorf("ParsedWithFixes = %v, want %v", rssFeed.ParsedWithFixes, tc.fixed)
+			}
+		})
+	}
+}
+
+func TestParseFeedRejectsNonFeeds(t *testing.T) {
+	for _, dat := range []string{
+		`<html><body><p>Not a feed & never was</p></body></html>`,
+		`this is not xml at all`,
+	} {
+		if _, err := parseFeed([]byte(dat)); err == nil {
+			t.Errorf("parseFeed(%q) succeeded, want an error", dat)
+		}
+	}
+}
+
+func TestParseFeedKeepsEscapedMarkupInContent(t *testing.T) {
+	tests := []struct {
+		name string
+		feed string
+	}{
+		{"content:encoded CDATA", `<rss xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel><title>T</title><item><title>x</title>` +
+			`<content:encoded><![CDATA[<p>Use &lt;b&gt; for bold</p>]]></content:encoded></item></channel></rss>`},
+		{"content:encoded escaped", `<rss xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel><title>T</title><item><title>x</title>` +
+			`<content:encoded>&lt;p&gt;Use &amp;lt;b&amp;gt; for bold&lt;/p&gt;</content:encoded></item></channel></rss>`},
+		{"atom html", `<feed xmlns="http://www.w3.org/2005/Atom"><title>T</title><entry><title>x</title>` +
+			`<content type="html">&lt;p&gt;Use &amp;lt;b&amp;gt; for bold&lt;/p&gt;</content></entry></feed>`},
+	}
+	for _, tc := range tests {
+		rssFeed, err := parseFeed([]byte(tc.feed))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		want := "<p>Use &lt;b&gt; for bold</p>"
+		if len(rssFeed.Channel.Item) != 1 || rssFeed.Channel.Item[0].Content != want {
+			t.Errorf("%s: items = %+v, want content %q", tc.name, rssFeed.Channel.Item, want)
+		}
+	}
+}
+
+func TestAlternateLink(t *testing.T) {
+	tests := []struct {
+		name  string
+		links []AtomLink
+		want  string
+	}{
+		{"none", nil, ""},
+		{"no rel", []AtomLink{{Href: "https://example.com/post"}}, "https://example.com/post"},
+		{"alternate after others", []AtomLink{
+			{Rel: "self", Href: "https://example.com/feed"},
+			{Rel: "alternate", Href: "https://example.com/post"},
+		}, "https://example.com/post"},
+		{"enclosure first", []AtomLink{
+			{Rel: "enclosure", Href: "https://example.com/episode.mp3"},
+			{Rel: "related", Href: "https://example.com/post"},
+		}, "https://example.com/post"},
+		{"only enclosures", []AtomLink{{Rel: "enclosure", Href: "https://example.com/episode.mp3"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := alternateLink(tt.links); got != tt.want {
+			t.Errorf("%s: alternateLink = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// A podcast entry whose only other link is its audio file
+	rssFeed, err := parseFeed([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"><title>T</title><entry><title>Episode</title>` +
+		`<link rel="enclosure" type="audio/mpeg" length="1234" href="https://example.com/episode.mp3"/>` +
+		`<link rel="replies" href="https://example.com/episode#comments"/></entry></feed>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link := rssFeed.Channel.Item[0].Link; link != "https://example.com/episode#comments" {
+		t.Errorf("entry link = %q, want the page, not the enclosure", link)
+	}
+}
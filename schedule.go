@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+const (
+	minAdaptiveInterval = 5 * time.Minute
+	maxAdaptiveInterval = 24 * time.Hour
+	// number of recent posts used to estimate how often a feed publishes
+	adaptiveSampleSize = 10
+	// longest wait before retrying a feed that keeps failing
+	maxFailureBackoff = 24 * time.Hour
+)
+
+var syUpdatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// scheduleHints are the publisher's requests on how often to fetch a feed.
+type scheduleHints struct {
+	// Minimum time between fetches from <ttl>, sy:updatePeriod and
+	// Cache-Control max-age, whichever is longest.
+	MinInterval time.Duration
+	SkipHours   map[int]bool
+	SkipDays    map[time.Weekday]bool
+}
+
+func feedScheduleHints(rssFeed *RSSFeed, header http.Header) scheduleHints {
+	hints := scheduleHints{
+		SkipHours: map[int]bool{},
+		SkipDays:  map[time.Weekday]bool{},
+	}
+	if header != nil {
+		hints.MinInterval = max(hints.MinInterval, cacheMaxAge(header.Get("Cache-Control")))
+	}
+	if rssFeed == nil {
+		return hints
+	}
+
+	channel := rssFeed.Channel
+	if ttl, err := strconv.Atoi(strings.TrimSpace(channel.TTL)); err == nil && ttl > 0 {
+		hints.MinInterval = max(hints.MinInterval, time.Duration(ttl)*time.Minute)
+	}
+	if period, ok := syUpdatePeriods[strings.ToLower(strings.TrimSpace(channel.UpdatePeriod))]; ok {
+		frequency, err := strconv.Atoi(strings.TrimSpace(channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			frequency = 1
+		}
+		hints.MinInterval = max(hints.MinInterval, period/time.Duration(frequency))
+	}
+	for _, hour := range channel.SkipHours {
+		if hour >= 0 && hour < 24 {
+			hints.SkipHours[hour] = true
+		}
+	}
+	for _, day := range channel.SkipDays {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(strings.TrimSpace(day), d.String()) {
+				hints.SkipDays[d] = true
+			}
+		}
+	}
+	return hints
+}
+
+// cacheMaxAge returns the max-age directive of a Cache-Control header.
+func cacheMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
+
+// adaptiveInterval fetches a feed about twice per average gap between its
+// recent posts, so busy feeds are polled often and quiet ones rarely.
+func adaptiveInterval(postDates []time.Time, fallback time.Duration) time.Duration {
+	if len(postDates) < 2 {
+		return fallback
+	}
+	newest, oldest := postDates[0], postDates[0]
+	for _, d := range postDates {
+		if d.After(newest) {
+			newest = d
+		}
+		if d.Before(oldest) {
+			oldest = d
+		}
+	}
+	averageGap := newest.Sub(oldest) / time.Duration(len(postDates)-1)
+
+	// A feed that has gone quiet since its last post shouldn't be polled
+	// at the rate it used to publish.
+	if sinceLast := time.Since(newest); sinceLast > averageGap {
+		averageGap = (averageGap + sinceLast) / 2
+	}
+	return min(max(averageGap/2, minAdaptiveInterval), maxAdaptiveInterval)
+}
+
+// nextFetchTime returns when a feed fetched at now should next be fetched,
+// moved past any hours and days the publisher asked to skip.
+func nextFetchTime(now time.Time, interval time.Duration, hints scheduleHints) time.Time {
+	next := now.Add(interval).UTC()
+	// skipHours and skipDays are in GMT; give up after a week in case
+	// every slot is skipped.
+	for i := 0; i < 24*7; i++ {
+		if !hints.SkipHours[next.Hour()] && !hints.SkipDays[next.Weekday()] {
+			break
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+// feedInterval returns how long to wait between fetches of feed: its fixed
+// interval when set, otherwise the default or adaptive interval, never less
+// than the publisher asked for.
+func feedInterval(s *state, feed database.Feed, hints scheduleHints) time.Duration {
+	interval := s.cfg.FetchInterval()
+	if feed.FetchInterval > 0 {
+		interval = time.Duration(feed.FetchInterval) * time.Second
+	} else if feed.AdaptiveInterval {
+		postDates, err := s.db.GetRecentPostDates(context.Background(), database.GetRecentPostDatesParams{
+			FeedID: feed.ID,
+			Limit:  adaptiveSampleSize,
+		})
+		if err != nil {
+			feedLog(feed.ID, feed.Url).Error("couldn't load post dates", "error", err)
+		} else {
+			interval = adaptiveInterval(postDates, interval)
+		}
+	}
+	return max(interval, hints.MinInterval)
+}
+
+// failureBackoff doubles interval for every failed fetch of feed in a row
+// before the one that just failed, as recorded in the fetch log, up to a
+// day. Only as many runs are read as it takes to reach that cap.
+func failureBackoff(s *state, feed database.Feed, interval time.Duration) time.Duration {
+	if interval <= 0 || interval >= maxFailureBackoff {
+		return min(interval, maxFailureBackoff)
+	}
+	doublings := int32(0)
+	for capped := interval; capped < maxFailureBackoff; capped *= 2 {
+		doublings++
+	}
+
+	errs, err := s.db.GetRecentFetchErrors(context.Background(), database.GetRecentFetchErrorsParams{
+		FeedID: feed.ID,
+		Limit:  doublings,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't load fetch log", "error", err)
+		return interval
+	}
+	for _, runErr := range errs {
+		if runErr == "" {
+			break
+		}
+		interval *= 2
+	}
+	return min(interval, maxFailureBackoff)
+}
+
+// scheduleFeed sets when feed is fetched next.
+func scheduleFeed(s *state, feed database.Feed, hints scheduleHints) {
+	next := nextFetchTime(time.Now(), feedInterval(s, feed, hints), hints)
+	err := s.db.ScheduleFeedFetch(context.Background(), database.ScheduleFeedFetchParams{
+		NextFetchAt: sql.NullTime{Time: next, Valid: true},
+		ID:          feed.ID,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't schedule feed", "error", err)
+	}
+}
+
+// scheduleFailedFeed sets when feed is retried after a failed fetch, backing
+// off further the longer it keeps failing.
+func scheduleFailedFeed(s *state, feed database.Feed) {
+	interval := failureBackoff(s, feed, feedInterval(s, feed, scheduleHints{}))
+	deferFeed(s, feed, time.Now().Add(interval))
+}
+
+// deferFeed postpones the next fetch of feed until the given time.
+func deferFeed(s *state, feed database.Feed, until time.Time) {
+	err := s.db.ScheduleFeedFetch(context.Background(), database.ScheduleFeedFetchParams{
+		NextFetchAt: sql.NullTime{Time: until.UTC(), Valid: true},
+		ID:          feed.ID,
+	})
+	if err != nil {
+		feedLog(feed.ID, feed.Url).Error("couldn't reschedule feed", "error", err)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func TestAdaptiveInterval(t *testing.T) {
+	now := time.Now()
+	hoursAgo := func(hours ...float64) []time.Time {
+		dates := make([]time.Time, len(hours))
+		for i, h := range hours {
+			dates[i] = now.Add(-time.Duration(h * float64(time.Hour)))
+		}
+		return dates
+	}
+	tests := []struct {
+		name  string
+		dates []time.Time
+		want  time.Duration
+	}{
+		{"no posts", nil, time.Hour},
+		{"one post", hoursAgo(1), time.Hour},
+		{"posts every 4 hours", hoursAgo(0, 4, 8, 12), 2 * time.Hour},
+		{"order doesn't matter", hoursAgo(8, 0, 12, 4), 2 * time.Hour},
+		{"quiet since the last post", hoursAgo(12, 16, 20), 4 * time.Hour},
+		{"busier than the minimum", hoursAgo(0, 0.01, 0.02), minAdaptiveInterval},
+		{"quieter than the maximum", hoursAgo(0, 24*30, 24*60), maxAdaptiveInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adaptiveInterval(tt.dates, time.Hour)
+			if got.Round(time.Second) != tt.want {
+				t.Errorf("adaptiveInterval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextFetchTime(t *testing.T) {
+	// A Monday
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		now   time.Time
+		hints scheduleHints
+		want  time.Time
+	}{
+		{
+			name: "no hints",
+			now:  now,
+			want: time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "skipped hour",
+			now:   now,
+			hints: scheduleHints{SkipHours: map[int]bool{11: true, 12: true}},
+			want:  time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "skipped day",
+			now:   now,
+			hints: scheduleHints{SkipDays: map[time.Weekday]bool{time.Monday: true}},
+			want:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "skipped hours are in GMT",
+			now:   time.Date(2024, 1, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			hints: scheduleHints{SkipHours: map[int]bool{11: true}},
+			want:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "every slot skipped",
+			now:  now,
+			hints: scheduleHints{SkipDays: map[time.Weekday]bool{
+				time.Sunday: true, time.Monday: true, time.Tuesday: true, time.Wednesday: true,
+				time.Thursday: true, time.Friday: true, time.Saturday: true,
+			}},
+			want: time.Date(2024, 1, 8, 11, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextFetchTime(tt.now, time.Hour, tt.hints)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextFetchTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedScheduleHints(t *testing.T) {
+	tests := []struct {
+		name      string
+		channel   string
+		header    http.Header
+		interval  time.Duration
+		skipHours []int
+		skipDays  []time.Weekday
+	}{
+		{name: "no hints"},
+		{name: "ttl", channel: "<ttl>90</ttl>", interval: 90 * time.Minute},
+		{name: "invalid ttl", channel: "<ttl>soon</ttl>"},
+		{
+			name:     "update period",
+			channel:  "<sy:updatePeriod>daily</sy:updatePeriod><sy:updateFrequency>4</sy:updateFrequency>",
+			interval: 6 * time.Hour,
+		},
+		{
+			name:     "longest hint wins",
+			channel:  "<ttl>30</ttl><sy:updatePeriod>hourly</sy:updatePeriod>",
+			header:   http.Header{"Cache-Control": {"public, max-age=7200"}},
+			interval: 2 * time.Hour,
+		},
+		{
+			name:      "skip hours and days",
+			channel:   "<skipHours><hour>0</hour><hour>23</hour><hour>24</hour></skipHours><skipDays><day>Saturday</day><day> sunday </day><day>Someday</day></skipDays>",
+			skipHours: []int{0, 23},
+			skipDays:  []time.Weekday{time.Saturday, time.Sunday},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `<rss xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><channel>` + tt.channel + `</channel></rss>`
+			var rssFeed RSSFeed
+			if err := xml.Unmarshal([]byte(doc), &rssFeed); err != nil {
+				t.Fatal(err)
+			}
+			hints := feedScheduleHints(&rssFeed, tt.header)
+			if hints.MinInterval != tt.interval {
+				t.Errorf("MinInterval = %v, want %v", hints.MinInterval, tt.interval)
+			}
+			if len(hints.SkipHours) != len(tt.skipHours) {
+				t.Errorf("SkipHours = %v, want %v", hints.SkipHours, tt.skipHours)
+			}
+			for _, hour := range tt.skipHours {
+				if !hints.SkipHours[hour] {
+					t.Errorf("hour %d isn't skipped", hour)
+				}
+			}
+			if len(hints.SkipDays) != len(tt.skipDays) {
+				t.Errorf("SkipDays = %v, want %v", hints.SkipDays, tt.skipDays)
+			}
+			for _, day := range tt.skipDays {
+				if !hints.SkipDays[day] {
+					t.Errorf("%v isn't skipped", day)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"max-age=300", 5 * time.Minute},
+		{"public, Max-Age=60, must-revalidate", time.Minute},
+		{`max-age="120"`, 2 * time.Minute},
+		{"s-maxage=600", 0},
+		{"max-age=0", 0},
+		{"max-age=-5", 0},
+		{"max-age=soon", 0},
+		{"no-cache", 0},
+	}
+	for _, tt := range tests {
+		if got := cacheMaxAge(tt.header); got != tt.want {
+			t.Errorf("cacheMaxAge(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFailureBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		runs     []string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"first fetch", nil, time.Hour, time.Hour},
+		{"after a success", []string{""}, time.Hour, time.Hour},
+		{"failing in a row", []string{"timeout", "timeout", "", "timeout"}, time.Hour, 4 * time.Hour},
+		{"capped at a day", []string{"timeout", "timeout", "timeout", "timeout", "timeout", "timeout"}, time.Hour, maxFailureBackoff},
+		{"interval above the cap", []string{"timeout"}, 48 * time.Hour, maxFailureBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestState(t)
+			feed := addTestFeed(t, s, "Test Feed", "https://example.com/feed.xml")
+			// runs are newest first
+			started := time.Now().UTC()
+			for _, runErr := range tt.runs {
+				started = started.Add(-time.Hour)
+				err := s.db.CreateFetchLog(context.Background(), database.CreateFetchLogParams{
+					ID:         uuid.New(),
+					FeedID:     feed.ID,
+					StartedAt:  started,
+					FinishedAt: started,
+					Error:      runErr,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := failureBackoff(s, feed, tt.interval); got != tt.want {
+				t.Errorf("failureBackoff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -35,3 +35,12 @@ WHERE feed_follows.user_id = $1;
 -- name: DeleteUserFeed :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2;
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = @to_feed_id
+WHERE feed_follows.feed_id = @from_feed_id
+AND feed_follows.user_id NOT IN (
+  SELECT existing.user_id FROM feed_follows existing
+  WHERE existing.feed_id = @to_feed_id
+);
@@ -0,0 +1,20 @@
+-- name: SetFeedHeader :exec
+INSERT INTO feed_headers (feed_id, name, value, created_at, updated_at)
+VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5
+)
+ON CONFLICT (feed_id, name) DO UPDATE
+SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at;
+
+-- name: GetFeedHeaders :many
+SELECT * FROM feed_headers
+WHERE feed_id = $1
+ORDER BY name;
+
+-- name: DeleteFeedHeaders :exec
+DELETE FROM feed_headers
+WHERE feed_id = $1;
@@ -0,0 +1,33 @@
+-- name: CreateFetchLog :exec
+INSERT INTO fetch_log (id, feed_id, started_at, finished_at, status_code, bytes, items_seen, items_new, items_updated, error)
+VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8,
+  $9,
+  $10
+);
+
+-- name: GetFetchLog :many
+SELECT fetch_log.*, feeds.name AS feed_name, feeds.url AS feed_url
+FROM fetch_log
+INNER JOIN feeds
+ON fetch_log.feed_id = feeds.id
+WHERE fetch_log.started_at >= @since
+AND (sqlc.narg('feed_id')::uuid IS NULL OR fetch_log.feed_id = sqlc.narg('feed_id'))
+ORDER BY fetch_log.started_at DESC;
+
+-- name: GetRecentFetchErrors :many
+SELECT error FROM fetch_log
+WHERE feed_id = $1
+ORDER BY started_at DESC
+LIMIT $2;
+
+-- name: DeleteFetchLogBefore :execrows
+DELETE FROM fetch_log
+WHERE started_at < $1;
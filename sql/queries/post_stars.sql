@@ -0,0 +1,19 @@
+-- name: StarPost :exec
+INSERT INTO post_stars (user_id, post_id, created_at)
+VALUES (
+  $1,
+  $2,
+  $3
+)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnstarPost :exec
+DELETE FROM post_stars
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetStarredPostsForUser :many
+SELECT posts.* FROM posts
+INNER JOIN post_stars
+ON post_stars.post_id = posts.id
+WHERE post_stars.user_id = $1
+ORDER BY post_stars.created_at DESC;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN content;
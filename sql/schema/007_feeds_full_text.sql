@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN fetch_full_text BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN fetch_full_text;
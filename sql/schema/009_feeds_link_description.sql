@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN link TEXT NOT NULL DEFAULT '',
+ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN link,
+DROP COLUMN description;
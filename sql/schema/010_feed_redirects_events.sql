@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN redirect_url TEXT NOT NULL DEFAULT '',
+ADD COLUMN redirect_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN disabled_at TIMESTAMP NULL;
+
+CREATE TABLE feed_events(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  feed_id UUID NOT NULL,
+  kind TEXT NOT NULL,
+  message TEXT NOT NULL,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_events;
+
+ALTER TABLE feeds
+DROP COLUMN redirect_url,
+DROP COLUMN redirect_count,
+DROP COLUMN disabled_at;
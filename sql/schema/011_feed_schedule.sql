@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN fetch_interval INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN adaptive_interval BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN next_fetch_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN fetch_interval,
+DROP COLUMN adaptive_interval,
+DROP COLUMN next_fetch_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN parsed_with_fixes BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN parsed_with_fixes;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE feed_headers(
+  feed_id UUID NOT NULL,
+  name TEXT NOT NULL,
+  value TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (feed_id, name),
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_headers;
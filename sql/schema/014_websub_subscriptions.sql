@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE websub_subscriptions(
+  feed_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  hub TEXT NOT NULL,
+  topic TEXT NOT NULL,
+  secret TEXT NOT NULL,
+  requested_at TIMESTAMP NOT NULL,
+  lease_expires_at TIMESTAMP NULL,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE websub_subscriptions;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE fetch_log(
+  id UUID PRIMARY KEY,
+  feed_id UUID NOT NULL,
+  started_at TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP NOT NULL,
+  status_code INTEGER NOT NULL,
+  bytes BIGINT NOT NULL,
+  items_seen INTEGER NOT NULL,
+  items_new INTEGER NOT NULL,
+  items_updated INTEGER NOT NULL,
+  error TEXT NOT NULL,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX fetch_log_started_at_idx ON fetch_log (started_at);
+
+-- +goose Down
+DROP TABLE fetch_log;
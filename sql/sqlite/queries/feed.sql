@@ -0,0 +1,38 @@
+
+-- name: CreateFeedFollow :one
+-- SQLite has no INSERT in WITH, so the names are looked up in RETURNING
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+VALUES (
+  ?1,
+  ?2,
+  ?3,
+  ?4,
+  ?5
+)
+RETURNING *,
+  (SELECT feeds.name FROM feeds WHERE feeds.id = feed_follows.feed_id) AS feed_name,
+  (SELECT users.name FROM users WHERE users.id = feed_follows.user_id) AS user_name;
+
+-- name: GetFeedFollowsForUser :many
+SELECT *,
+  feeds.name AS feed_name,
+  users.name AS user_name
+FROM feed_follows
+INNER JOIN users
+ON feed_follows.user_id = users.id
+INNER JOIN feeds
+ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = ?1;
+
+-- name: DeleteUserFeed :exec
+DELETE FROM feed_follows
+WHERE user_id = ?1 AND feed_id = ?2;
+
+-- name: MoveFeedFollows :exec
+UPDATE feed_follows
+SET feed_id = @to_feed_id
+WHERE feed_follows.feed_id = @from_feed_id
+AND feed_follows.user_id NOT IN (
+  SELECT existing.user_id FROM feed_follows existing
+  WHERE existing.feed_id = @to_feed_id
+);
@@ -0,0 +1,20 @@
+-- name: CreateFeedEvent :exec
+INSERT INTO feed_events (id, created_at, feed_id, kind, message)
+VALUES (
+  ?1,
+  ?2,
+  ?3,
+  ?4,
+  ?5
+);
+
+-- name: GetFeedEventsForUser :many
+SELECT feed_events.*, feeds.name AS feed_name
+FROM feed_events
+INNER JOIN feeds
+ON feed_events.feed_id = feeds.id
+INNER JOIN feed_follows
+ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = ?1
+ORDER BY feed_events.created_at DESC
+LIMIT ?2;
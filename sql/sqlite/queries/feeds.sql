@@ -0,0 +1,89 @@
+-- name: AddFeed :one
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, link, description) 
+VALUES ( 
+  ?1,
+  ?2,
+  ?3,
+  ?4,
+  ?5,
+  ?6,
+  ?7,
+  ?8
+)
+RETURNING *;
+
+-- name: GetFeeds :many
+SELECT * FROM feeds;
+
+-- name: GetFeedByURL :one
+SELECT * FROM feeds
+WHERE url = ?1 LIMIT 1;
+
+-- name: MarkFeedFetched :exec
+UPDATE feeds
+SET last_fetched_at = ?1, updated_at = ?1
+WHERE id = ?2;
+
+-- name: GetFeedsToFetch :many
+SELECT * FROM feeds
+WHERE disabled_at IS NULL
+AND (next_fetch_at IS NULL OR next_fetch_at <= ?1)
+ORDER BY next_fetch_at NULLS FIRST, last_fetched_at NULLS FIRST
+LIMIT ?2;
+
+-- name: SetFeedFullText :exec
+UPDATE feeds
+SET fetch_full_text = ?1, updated_at = ?2
+WHERE id = ?3;
+
+-- name: SetFeedParsedWithFixes :exec
+UPDATE feeds
+SET parsed_with_fixes = ?1
+WHERE id = ?2;
+
+-- name: GetFeed :one
+SELECT * FROM feeds
+WHERE id = ?1 LIMIT 1;
+
+-- name: GetFeedsByName :many
+SELECT * FROM feeds
+WHERE lower(name) = lower(?1);
+
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = ?1, updated_at = ?2, redirect_url = '', redirect_count = 0
+WHERE id = ?3;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = ?1;
+
+-- name: RecordFeedRedirect :exec
+UPDATE feeds
+SET redirect_url = ?1, redirect_count = ?2, updated_at = ?3
+WHERE id = ?4;
+
+-- name: SetFeedDisabled :exec
+UPDATE feeds
+SET disabled_at = ?1, updated_at = ?2
+WHERE id = ?3;
+
+-- name: ScheduleFeedFetch :exec
+UPDATE feeds
+SET next_fetch_at = ?1
+WHERE id = ?2;
+
+-- name: SetFeedInterval :exec
+UPDATE feeds
+SET fetch_interval = ?1, adaptive_interval = ?2, next_fetch_at = NULL, updated_at = ?3
+WHERE id = ?4;
+
+-- name: CountDueFeeds :one
+SELECT count(*) FROM feeds
+WHERE disabled_at IS NULL
+AND (next_fetch_at IS NULL OR next_fetch_at <= ?1);
+
+-- name: SetFeedRetention :exec
+UPDATE feeds
+SET retention_days = ?1, retention_items = ?2, updated_at = ?3
+WHERE id = ?4;
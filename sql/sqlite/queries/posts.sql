@@ -0,0 +1,77 @@
+-- name: CreatePost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content) 
+VALUES ( 
+  ?1,
+  ?2,
+  ?3,
+  ?4,
+  ?5,
+  ?6,
+  ?7,
+  ?8,
+  ?9
+)
+RETURNING *;
+
+-- name: GetPostForUser :many
+SELECT * FROM posts
+ORDER BY published_at ASC
+LIMIT ?1;
+
+-- name: GetPost :one
+SELECT * FROM posts
+WHERE id = ?1 LIMIT 1;
+
+-- name: GetPostByURL :one
+SELECT * FROM posts
+WHERE url = ?1 LIMIT 1;
+
+-- name: GetPosts :many
+SELECT * FROM posts
+ORDER BY created_at ASC;
+
+-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = @to_feed_id
+WHERE feed_id = @from_feed_id;
+
+-- name: UpdatePostURL :exec
+UPDATE posts
+SET url = ?1, updated_at = ?2
+WHERE id = ?3;
+
+-- name: UpdatePostContent :exec
+UPDATE posts
+SET title = ?1, description = ?2, content = ?3, updated_at = ?4
+WHERE id = ?5;
+
+-- name: DeletePost :exec
+DELETE FROM posts
+WHERE id = ?1;
+
+-- name: GetRecentPostDates :many
+SELECT published_at FROM posts
+WHERE feed_id = ?1
+ORDER BY published_at DESC
+LIMIT ?2;
+
+-- name: CountNewerPosts :one
+SELECT count(*) FROM posts
+WHERE feed_id = ?1 AND published_at > ?2;
+
+-- name: GetPrunablePosts :many
+SELECT id, title, url, published_at FROM (
+  SELECT id, title, url, published_at,
+    row_number() OVER (ORDER BY published_at DESC, created_at DESC) AS position
+  FROM posts
+  WHERE feed_id = @feed_id
+) AS ranked
+WHERE (
+  (sqlc.narg('published_before') IS NOT NULL AND published_at < sqlc.narg('published_before'))
+  OR (CAST(@keep_items AS INTEGER) > 0 AND position > CAST(@keep_items AS INTEGER))
+)
+AND NOT EXISTS (
+  SELECT 1 FROM post_stars
+  WHERE post_stars.post_id = ranked.id
+)
+ORDER BY published_at ASC;
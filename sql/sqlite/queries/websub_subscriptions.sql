@@ -0,0 +1,41 @@
+-- name: SaveWebSubSubscription :exec
+INSERT INTO websub_subscriptions (feed_id, created_at, updated_at, hub, topic, secret, requested_at)
+VALUES (
+  ?1,
+  ?2,
+  ?3,
+  ?4,
+  ?5,
+  ?6,
+  ?7
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+  hub = EXCLUDED.hub,
+  topic = EXCLUDED.topic,
+  secret = EXCLUDED.secret,
+  requested_at = EXCLUDED.requested_at,
+  lease_expires_at = NULL;
+
+-- name: GetWebSubSubscription :one
+SELECT * FROM websub_subscriptions
+WHERE feed_id = ?1;
+
+-- name: MarkWebSubRequested :exec
+UPDATE websub_subscriptions
+SET requested_at = ?1, updated_at = ?1
+WHERE feed_id = ?2;
+
+-- name: SetWebSubLease :exec
+UPDATE websub_subscriptions
+SET lease_expires_at = ?1, updated_at = ?2
+WHERE feed_id = ?3;
+
+-- name: GetWebSubSubscriptionsToRenew :many
+SELECT * FROM websub_subscriptions
+WHERE lease_expires_at < ?1
+AND requested_at < ?2;
+
+-- name: DeleteWebSubSubscription :exec
+DELETE FROM websub_subscriptions
+WHERE feed_id = ?1;
@@ -0,0 +1,170 @@
+-- +goose Up
+-- The SQLite schema starts from where the PostgreSQL one is at 016, later
+-- changes are made to both.
+CREATE TABLE users(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE feeds(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  name TEXT NOT NULL,
+  url TEXT UNIQUE NOT NULL,
+  user_id UUID NOT NULL,
+  last_fetched_at TIMESTAMP NULL,
+  fetch_full_text BOOLEAN NOT NULL DEFAULT false,
+  link TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  redirect_url TEXT NOT NULL DEFAULT '',
+  redirect_count INTEGER NOT NULL DEFAULT 0,
+  disabled_at TIMESTAMP NULL,
+  fetch_interval INTEGER NOT NULL DEFAULT 0,
+  adaptive_interval BOOLEAN NOT NULL DEFAULT false,
+  next_fetch_at TIMESTAMP NULL,
+  parsed_with_fixes BOOLEAN NOT NULL DEFAULT false,
+  retention_days INTEGER NULL,
+  retention_items INTEGER NULL,
+  CONSTRAINT fk_user_id
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE
+);
+
+CREATE TABLE feed_follows(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL,
+  feed_id UUID NOT NULL,
+  CONSTRAINT fk_user_id
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE,
+  UNIQUE(user_id, feed_id)
+);
+
+CREATE TABLE posts(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  title TEXT NOT NULL,
+  url TEXT UNIQUE NOT NULL,
+  description TEXT NOT NULL,
+  published_at TIMESTAMP NOT NULL,
+  feed_id UUID NOT NULL,
+  content TEXT NOT NULL DEFAULT '',
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+CREATE TABLE enclosures(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  post_id UUID NOT NULL,
+  url TEXT NOT NULL,
+  mime_type TEXT NOT NULL,
+  length BIGINT NOT NULL,
+  duration INTEGER NOT NULL,
+  CONSTRAINT fk_post_id
+  FOREIGN KEY (post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE,
+  UNIQUE(post_id, url)
+);
+
+CREATE TABLE feed_events(
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  feed_id UUID NOT NULL,
+  kind TEXT NOT NULL,
+  message TEXT NOT NULL,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+CREATE TABLE feed_headers(
+  feed_id UUID NOT NULL,
+  name TEXT NOT NULL,
+  value TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (feed_id, name),
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+CREATE TABLE websub_subscriptions(
+  feed_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  hub TEXT NOT NULL,
+  topic TEXT NOT NULL,
+  secret TEXT NOT NULL,
+  requested_at TIMESTAMP NOT NULL,
+  lease_expires_at TIMESTAMP NULL,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+CREATE TABLE fetch_log(
+  id UUID PRIMARY KEY,
+  feed_id UUID NOT NULL,
+  started_at TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP NOT NULL,
+  status_code INTEGER NOT NULL,
+  bytes BIGINT NOT NULL,
+  items_seen INTEGER NOT NULL,
+  items_new INTEGER NOT NULL,
+  items_updated INTEGER NOT NULL,
+  error TEXT NOT NULL,
+  CONSTRAINT fk_feed_id
+  FOREIGN KEY (feed_id)
+  REFERENCES feeds(id)
+  ON DELETE CASCADE
+);
+
+CREATE INDEX fetch_log_started_at_idx ON fetch_log (started_at);
+
+CREATE TABLE post_stars(
+  user_id UUID NOT NULL,
+  post_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, post_id),
+  CONSTRAINT fk_user_id
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+  CONSTRAINT fk_post_id
+  FOREIGN KEY (post_id)
+  REFERENCES posts(id)
+  ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE post_stars;
+DROP TABLE fetch_log;
+DROP TABLE websub_subscriptions;
+DROP TABLE feed_headers;
+DROP TABLE feed_events;
+DROP TABLE enclosures;
+DROP TABLE posts;
+DROP TABLE feed_follows;
+DROP TABLE feeds;
+DROP TABLE users;
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+	"github.com/mortalglitch/gator/internal/sanitize"
+)
+
+const (
+	// lease asked of hubs; they may grant a different one
+	webSubLease = 10 * 24 * time.Hour
+	// subscriptions are renewed this long before their lease runs out
+	webSubRenewBefore = 24 * time.Hour
+	// how long an unanswered subscription request is left pending
+	webSubPendingTimeout = time.Hour
+	// feeds pushed by a hub are still polled this often, in case a push is
+	// lost
+	webSubPollInterval = 24 * time.Hour
+)
+
+// webSubscriber subscribes feeds to WebSub hubs on behalf of gator serve.
+type webSubscriber struct {
+	// callbackBase is the public URL hubs reach the callback handlers at
+	callbackBase string
+	client       *http.Client
+}
+
+func newWebSubscriber(publicURL string, client *http.Client) *webSubscriber {
+	return &webSubscriber{
+		callbackBase: strings.TrimSuffix(publicURL, "/") + "/websub/",
+		client:       client,
+	}
+}
+
+func (w *webSubscriber) callbackURL(feedID uuid.UUID) string {
+	return w.callbackBase + feedID.String()
+}
+
+// request asks hub to subscribe or unsubscribe callback to topic. The hub
+// confirms asynchronously by calling back.
+func (w *webSubscriber) request(ctx context.Context, mode, hub, topic, callback, secret string) error {
+	form := url.Values{
+		"hub.mode":     {mode},
+		"hub.topic":    {topic},
+		"hub.callback": {callback},
+	}
+	if mode == "subscribe" {
+		form.Set("hub.lease_seconds", strconv.Itoa(int(webSubLease/time.Second)))
+		form.Set("hub.secret", secret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("hub %s refused %s: %s %s", hub, mode, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func newWebSubSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// validSignature checks an X-Hub-Signature header ("sha256=<hex>") against
+// the HMAC of body keyed with the subscription's secret.
+func validSignature(signature string, body []byte, secret string) bool {
+	method, digest, ok := strings.Cut(signature, "=")
+	if !ok {
+		return false
+	}
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// verifyIntent answers a hub's verification request for sub, which is nil
+// when the feed has no subscription. It returns the challenge to echo and,
+// for subscriptions, the lease granted; ok is false when the request must be
+// refused.
+func verifyIntent(sub *database.WebsubSubscription, query url.Values) (challenge string, lease time.Duration, ok bool) {
+	challenge = query.Get("hub.challenge")
+	if challenge == "" {
+		return "", 0, false
+	}
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		if sub == nil || query.Get("hub.topic") != sub.Topic {
+			return "", 0, false
+		}
+		seconds, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || seconds <= 0 {
+			seconds = int(webSubLease / time.Second)
+		}
+		return challenge, time.Duration(seconds) * time.Second, true
+	case "unsubscribe":
+		// Only confirm unsubscribing from feeds we dropped ourselves
+		if sub != nil && query.Get("hub.topic") == sub.Topic {
+			return "", 0, false
+		}
+		return challenge, 0, true
+	}
+	return "", 0, false
+}
+
+// webSubTopic is the URL a feed is subscribed under: its self link when it
+// has one, its URL otherwise.
+func webSubTopic(feed database.Feed, rssFeed *RSSFeed) string {
+	if rssFeed.Self == "" {
+		return feed.Url
+	}
+	return sanitize.ResolveURL(rssFeed.Self, feedBaseURL(feed.Url, ""))
+}
+
+// subscribeWebSub subscribes feed to the hub it advertises, unless it
+// already is or a request is pending. It only does anything under gator
+// serve, which can receive the hub's callbacks.
+func subscribeWebSub(s *state, feed database.Feed, rssFeed *RSSFeed) {
+	if s.websub == nil || rssFeed.Hub == "" {
+		return
+	}
+	hub := sanitize.ResolveURL(rssFeed.Hub, feedBaseURL(feed.Url, ""))
+	topic := webSubTopic(feed, rssFeed)
+
+	log := feedLog(feed.ID, feed.Url)
+	sub, err := s.db.GetWebSubSubscription(context.Background(), feed.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error("couldn't load WebSub subscription", "error", err)
+		return
+	}
+	if err == nil && sub.Hub == hub && sub.Topic == topic {
+		if sub.LeaseExpiresAt.Valid || time.Since(sub.RequestedAt) < webSubPendingTimeout {
+			// Renewals are left to renewWebSubSubscriptions
+			return
+		}
+	}
+
+	secret, err := newWebSubSecret()
+	if err != nil {
+		log.Error("couldn't create WebSub secret", "error", err)
+		return
+	}
+	err = s.db.SaveWebSubSubscription(context.Background(), database.SaveWebSubSubscriptionParams{
+		FeedID:      feed.ID,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+		Hub:         hub,
+		Topic:       topic,
+		Secret:      secret,
+		RequestedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Error("couldn't save WebSub subscription", "error", err)
+		return
+	}
+
+	err = s.websub.request(context.Background(), "subscribe", hub, topic, s.websub.callbackURL(feed.ID), secret)
+	if err != nil {
+		log.Warn("couldn't subscribe to hub", "hub", hub, "error", err)
+		return
+	}
+	log.Info("requested WebSub subscription", "hub", hub, "topic", topic)
+}
+
+// renewWebSubSubscriptions asks hubs to extend leases that are about to run
+// out.
+func renewWebSubSubscriptions(s *state) {
+	if s.websub == nil {
+		return
+	}
+	subs, err := s.db.GetWebSubSubscriptionsToRenew(context.Background(), database.GetWebSubSubscriptionsToRenewParams{
+		LeaseExpiresAt: sql.NullTime{Time: time.Now().UTC().Add(webSubRenewBefore), Valid: true},
+		RequestedAt:    time.Now().UTC().Add(-webSubPendingTimeout),
+	})
+	if err != nil {
+		slog.Error("couldn't load WebSub subscriptions", "error", err)
+		return
+	}
+
+	for _, sub := range subs {
+		err := s.db.MarkWebSubRequested(context.Background(), database.MarkWebSubRequestedParams{
+			RequestedAt: time.Now().UTC(),
+			FeedID:      sub.FeedID,
+		})
+		if err != nil {
+			slog.Error("couldn't update WebSub subscription", "feed_id", sub.FeedID, "url", sub.Topic, "error", err)
+			continue
+		}
+		err = s.websub.request(context.Background(), "subscribe", sub.Hub, sub.Topic, s.websub.callbackURL(sub.FeedID), sub.Secret)
+		if err != nil {
+			slog.Warn("couldn't renew WebSub subscription", "feed_id", sub.FeedID, "url", sub.Topic, "hub", sub.Hub, "error", err)
+		}
+	}
+}
+
+// hasWebSubLease reports whether a hub is currently pushing feed to us.
+func hasWebSubLease(s *state, feedID uuid.UUID) bool {
+	if s.websub == nil {
+		return false
+	}
+	sub, err := s.db.GetWebSubSubscription(context.Background(), feedID)
+	if err != nil {
+		return false
+	}
+	return sub.LeaseExpiresAt.Valid && sub.LeaseExpiresAt.Time.After(time.Now())
+}
+
+// handleWebSubVerify answers hubs confirming a subscribe or unsubscribe
+// request, or denying a subscription. Each request uses the state current
+// holds when it arrives.
+func handleWebSubVerify(current *atomic.Pointer[state]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := current.Load()
+		feedID, err := uuid.Parse(r.PathValue("feedID"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+
+		var sub *database.WebsubSubscription
+		found, err := s.db.GetWebSubSubscription(r.Context(), feedID)
+		if err == nil {
+			sub = &found
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "couldn't load subscription", http.StatusInternalServerError)
+			return
+		}
+
+		if query.Get("hub.mode") == "denied" {
+			if sub != nil {
+				err = s.db.DeleteWebSubSubscription(r.Context(), feedID)
+				if err != nil {
+					slog.Error("couldn't delete WebSub subscription", "feed_id", feedID, "url", sub.Topic, "error", err)
+				}
+				recordFeedEvent(s, feedID, feedEventWebSub, fmt.Sprintf("hub denied the subscription: %s", query.Get("hub.reason")))
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		challenge, lease, ok := verifyIntent(sub, query)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if lease > 0 {
+			err = s.db.SetWebSubLease(r.Context(), database.SetWebSubLeaseParams{
+				LeaseExpiresAt: sql.NullTime{Time: time.Now().UTC().Add(lease), Valid: true},
+				UpdatedAt:      time.Now().UTC(),
+				FeedID:         feedID,
+			})
+			if err != nil {
+				http.Error(w, "couldn't save subscription", http.StatusInternalServerError)
+				return
+			}
+			slog.Info("WebSub subscription confirmed", "feed_id", feedID, "url", sub.Topic, "lease", lease)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, challenge)
+	}
+}
+
+// handleWebSubPush ingests content a hub distributes, through the same path
+// as a fetched feed. Pushes whose signature doesn't match are acknowledged
+// and dropped, as the spec asks.
+func handleWebSubPush(current *atomic.Pointer[state]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := current.Load()
+		feedID, err := uuid.Parse(r.PathValue("feedID"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		sub, err := s.db.GetWebSubSubscription(r.Context(), feedID)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		feed, err := s.db.GetFeed(r.Context(), feedID)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		log := feedLog(feed.ID, feed.Url)
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.fetcher.maxBodySize))
+		if err != nil {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !validSignature(r.Header.Get("X-Hub-Signature"), body, sub.Secret) {
+			log.Warn("dropped WebSub push with a bad signature")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		rssFeed, err := parsePushedFeed(r.Header.Get("Content-Type"), body)
+		if err != nil {
+			log.Warn("couldn't parse WebSub push", "error", err)
+			http.Error(w, "couldn't parse feed", http.StatusBadRequest)
+			return
+		}
+		stats, err := ingestFeed(s, feed, rssFeed)
+		if err != nil {
+			log.Error("couldn't save WebSub push", "error", err)
+		}
+		log.Info("received WebSub push", "items", stats.Seen, "new", stats.New, "updated", stats.Updated)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// parsePushedFeed decodes a pushed body the way fetched feeds are decoded.
+func parsePushedFeed(contentType string, body []byte) (*RSSFeed, error) {
+	dat, err := decodeCharset(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return parseFeed(dat)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mortalglitch/gator/internal/database"
+)
+
+func TestParseFeedHubLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		feed string
+		link string
+	}{
+		{
+			name: "rss",
+			feed: `<rss xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>t</title>
+				<link>https://example.com/</link>
+				<atom:link rel="hub" href="https://hub.example.com/"/>
+				<atom:link rel="self" href="https://example.com/feed.xml"/>
+			</channel></rss>`,
+			link: "https://example.com/",
+		},
+		{
+			name: "atom",
+			feed: `<feed xmlns="http://www.w3.org/2005/Atom"><title>t</title>
+				<link rel="self" href="https://example.com/feed.xml"/>
+				<link href="https://example.com/"/>
+				<link rel="hub" href="https://hub.example.com/"/>
+			</feed>`,
+			link: "https://example.com/",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rssFeed, err := parseFeed([]byte(tc.feed))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rssFeed.Hub != "https://hub.example.com/" || rssFeed.Self != "https://example.com/feed.xml" {
+				t.Errorf("hub = %q, self = %q", rssFeed.Hub, rssFeed.Self)
+			}
+			// atom:link used to overwrite the channel link
+			if rssFeed.Channel.Link != tc.link {
+				t.Errorf("link = %q, want %q", rssFeed.Channel.Link, tc.link)
+			}
+		})
+	}
+}
+
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// standInHub is a minimal WebSub hub: it verifies every subscription
+// request with the subscriber, then pushes content to the callback.
+type standInHub struct {
+	t       *testing.T
+	content []byte
+
+	mu       sync.Mutex
+	requests []url.Values
+	verified chan string
+}
+
+func (h *standInHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	h.requests = append(h.requests, r.PostForm)
+	h.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+
+	form := r.PostForm
+	go func() {
+		callback := form.Get("hub.callback")
+		verify := url.Values{
+			"hub.mode":          {form.Get("hub.mode")},
+			"hub.topic":         {form.Get("hub.topic")},
+			"hub.challenge":     {"challenge-123"},
+			"hub.lease_seconds": {"3600"},
+		}
+		resp, err := http.Get(callback + "?" + verify.Encode())
+		if err != nil {
+			h.t.Errorf("verification: %v", err)
+			return
+		}
+		echoed, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(echoed) != "challenge-123" {
+			h.verified <- ""
+			return
+		}
+		h.verified <- form.Get("hub.topic")
+
+		secret := form.Get("hub.secret")
+		for _, signature := range []string{"sha256=00", sign(h.content, secret)} {
+			req, _ := http.NewRequest("POST", callback, strings.NewReader(string(h.content)))
+			req.Header.Set("Content-Type", "application/rss+xml")
+			req.Header.Set("X-Hub-Signature", signature)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				h.t.Errorf("push: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}
+	}()
+}
+
+func TestWebSubWithStandInHub(t *testing.T) {
+	topic := "https://example.com/feed.xml"
+	content := []byte(`<rss><channel><title>Pushed</title><item><title>Fresh</title><link>https://example.com/fresh</link></item></channel></rss>`)
+	hub := &standInHub{t: t, content: content, verified: make(chan string, 1)}
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+
+	// The subscriber side: the same verification and signature checks as
+	// gator serve, with the subscription kept in memory.
+	var mu sync.Mutex
+	var sub *database.WebsubSubscription
+	var lease time.Duration
+	pushed := make(chan *RSSFeed, 2)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /websub/{feedID}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		challenge, granted, ok := verifyIntent(sub, r.URL.Query())
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		lease = granted
+		io.WriteString(w, challenge)
+	})
+	mux.HandleFunc("POST /websub/{feedID}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		secret := sub.Secret
+		mu.Unlock()
+		if !validSignature(r.Header.Get("X-Hub-Signature"), body, secret) {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		rssFeed, err := parsePushedFeed(r.Header.Get("Content-Type"), body)
+		if err != nil {
+			t.Errorf("parsePushedFeed: %v", err)
+		}
+		pushed <- rssFeed
+		w.WriteHeader(http.StatusAccepted)
+	})
+	subscriberServer := httptest.NewServer(mux)
+	defer subscriberServer.Close()
+
+	secret, err := newWebSubSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedID := uuid.New()
+	mu.Lock()
+	sub = &database.WebsubSubscription{FeedID: feedID, Hub: hubServer.URL, Topic: topic, Secret: secret}
+	mu.Unlock()
+
+	subscriber := newWebSubscriber(subscriberServer.URL+"/", http.DefaultClient)
+	err = subscriber.request(context.Background(), "subscribe", hubServer.URL, topic, subscriber.callbackURL(feedID), secret)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	select {
+	case got := <-hub.verified:
+		if got != topic {
+			t.Fatalf("verification failed for topic %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hub never verified the subscription")
+	}
+
+	select {
+	case rssFeed := <-pushed:
+		if len(rssFeed.Channel.Item) != 1 || rssFeed.Channel.Item[0].Title != "Fresh" {
+			t.Errorf("pushed items = %+v", rssFeed.Channel.Item)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no push received")
+	}
+	select {
+	case <-pushed:
+		t.Error("push with a bad signature was accepted")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if len(hub.requests) != 1 {
+		t.Fatalf("hub got %d requests, want 1", len(hub.requests))
+	}
+	form := hub.requests[0]
+	if form.Get("hub.mode") != "subscribe" || form.Get("hub.callback") != subscriberServer.URL+"/websub/"+feedID.String() || form.Get("hub.secret") != secret {
+		t.Errorf("subscription request = %v", form)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if lease != time.Hour {
+		t.Errorf("lease = %v, want the 1h the hub granted", lease)
+	}
+}
+
+func TestVerifyIntent(t *testing.T) {
+	sub := &database.WebsubSubscription{Topic: "https://example.com/feed"}
+	tests := []struct {
+		name  string
+		sub   *database.WebsubSubscription
+		query url.Values
+		ok    bool
+	}{
+		{"subscribe", sub, url.Values{"hub.mode": {"subscribe"}, "hub.topic": {sub.Topic}, "hub.challenge": {"c"}}, true},
+		{"other topic", sub, url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://evil.example/"}, "hub.challenge": {"c"}}, false},
+		{"not subscribed", nil, url.Values{"hub.mode": {"subscribe"}, "hub.topic": {sub.Topic}, "hub.challenge": {"c"}}, false},
+		{"no challenge", sub, url.Values{"hub.mode": {"subscribe"}, "hub.topic": {sub.Topic}}, false},
+		{"unsubscribe dropped feed", nil, url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {sub.Topic}, "hub.challenge": {"c"}}, true},
+		{"unsubscribe live feed", sub, url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {sub.Topic}, "hub.challenge": {"c"}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			challenge, _, ok := verifyIntent(tc.sub, tc.query)
+			if ok != tc.ok || (ok && challenge != "c") {
+				t.Errorf("verifyIntent = %q, %v; want ok %v", challenge, ok, tc.ok)
+			}
+		})
+	}
+}